@@ -0,0 +1,31 @@
+package api
+
+import (
+	"blog-backend/controller"
+	"blog-backend/middleware"
+	"blog-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupAdminRoutes 配置管理员相关路由
+func setupAdminRoutes(api *gin.RouterGroup) {
+	// 管理员路由（需要登录会话且拥有用户管理权限）
+	admin := api.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(), middleware.RequireSession(), middleware.RequirePermission(services.PermManageUsers))
+	{
+		admin.GET("/users", controller.ListUsers)
+		admin.PUT("/users/:id/role", controller.GrantRole)
+		admin.DELETE("/users/:id/role", controller.RevokeRole)
+		admin.POST("/users/:id/unlock", controller.UnlockUser)
+	}
+
+	// 分类管理（编辑和管理员）
+	categories := api.Group("/admin/categories")
+	categories.Use(middleware.AuthMiddleware(), middleware.RequireSession(), middleware.RequirePermission(services.PermManageCategories))
+	{
+		categories.POST("", controller.CreateCategory)
+		categories.PUT("/:id", controller.UpdateCategory)
+		categories.DELETE("/:id", controller.DeleteCategory)
+	}
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"blog-backend/controller"
+	"blog-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupCategoryRoutes 配置分类相关路由
+func setupCategoryRoutes(api *gin.RouterGroup) {
+	categories := api.Group("/categories")
+	{
+		// 获取分类树、分类详情和分类下的文章（无需认证）
+		categories.GET("", controller.GetCategories)
+		categories.GET("/:slug", controller.GetCategory)
+		categories.GET("/:slug/posts", middleware.OptionalAuthMiddleware(), controller.GetCategoryPosts)
+	}
+}
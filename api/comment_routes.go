@@ -3,6 +3,7 @@ package api
 import (
 	"blog-backend/middleware"
 	"blog-backend/controller"
+	"blog-backend/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,16 +13,17 @@ func setupCommentRoutes(api *gin.RouterGroup) {
 	// 评论相关路由
 	comments := api.Group("/posts/:id/comments")
 	{
-		// 获取评论列表（无需认证）
-		comments.GET("", controller.GetComments)
+		// 获取评论列表（无需认证，登录用户可以查看自己草稿下的评论）
+		comments.GET("", middleware.OptionalAuthMiddleware(), controller.GetComments)
 
 		// 创建评论（需要认证）
-		comments.POST("", middleware.AuthMiddleware(), controller.CreateComment)
+		comments.POST("", middleware.AuthMiddleware(), middleware.RequireScope(services.ScopeCommentsWrite), controller.CreateComment)
 	}
 
 	// 更新评论（需要认证）
-	api.PUT("/comments/:id", middleware.AuthMiddleware(), controller.UpdateComment)
+	// 个人访问令牌的权限范围在控制器中根据评论归属进一步校验
+	api.PUT("/comments/:id", middleware.AuthMiddleware(), middleware.RequireScope(services.ScopeCommentsWrite, services.ScopeCommentsModerate), controller.UpdateComment)
 
 	// 删除评论（需要认证）
-	api.DELETE("/comments/:id", middleware.AuthMiddleware(), controller.DeleteComment)
+	api.DELETE("/comments/:id", middleware.AuthMiddleware(), middleware.RequireScope(services.ScopeCommentsWrite, services.ScopeCommentsModerate), controller.DeleteComment)
 }
\ No newline at end of file
@@ -3,6 +3,7 @@ package api
 import (
 	"blog-backend/middleware"
 	"blog-backend/controller"
+	"blog-backend/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,14 +16,29 @@ func setupPostRoutes(api *gin.RouterGroup) {
 		// 获取文章列表和详情（无需认证）
 		posts.GET("", middleware.OptionalAuthMiddleware(), controller.GetPosts)
 		posts.GET("/:id", middleware.OptionalAuthMiddleware(), controller.GetPost)
+		posts.GET("/by-slug/:slug", middleware.OptionalAuthMiddleware(), controller.GetPostBySlug)
 
 		// 创建、更新、删除文章（需要认证）
 		authPosts := posts.Group("/")
-		authPosts.Use(middleware.AuthMiddleware())
+		authPosts.Use(middleware.AuthMiddleware(), middleware.RequireScope(services.ScopePostsWrite))
 		{
 			authPosts.POST("", controller.CreatePost)
 			authPosts.PUT("/:id", controller.UpdatePost)
 			authPosts.DELETE("/:id", controller.DeletePost)
+
+			// 文章状态
+			authPosts.POST("/:id/publish", controller.PublishPost)
+			authPosts.POST("/:id/unpublish", controller.UnpublishPost)
+			authPosts.POST("/:id/archive", controller.ArchivePost)
+
+			// 定时发布
+			authPosts.PUT("/:id/schedule", controller.SchedulePost)
+			authPosts.DELETE("/:id/schedule", controller.UnschedulePost)
+
+			// 历史版本
+			authPosts.GET("/:id/revisions", controller.GetPostRevisions)
+			authPosts.GET("/:id/revisions/:rev/diff", controller.DiffPostRevision)
+			authPosts.POST("/:id/revisions/:rev/restore", controller.RestorePostRevision)
 		}
 	}
 }
\ No newline at end of file
@@ -1,11 +1,23 @@
 package api
 
 import (
+	"blog-backend/config"
+	"blog-backend/controller"
+
 	"github.com/gin-gonic/gin"
 )
 
+// SetupTrustedProxies 按配置设置可信代理
+// gin默认信任所有代理，任何人都可以通过伪造 X-Forwarded-For 改变登录限流使用的客户端IP
+func SetupTrustedProxies(router *gin.Engine) error {
+	return router.SetTrustedProxies(config.GetServerConfig().TrustedProxies)
+}
+
 // SetupRoutes 配置所有API路由
 func SetupRoutes(router *gin.Engine) {
+	// 公开的验证公钥，供其它服务验证访问令牌
+	router.GET("/.well-known/jwks.json", controller.JWKS)
+
 	// API路由组
 	api := router.Group("/api/v1")
 	{
@@ -17,5 +29,20 @@ func SetupRoutes(router *gin.Engine) {
 		
 		// 设置评论相关路由
 		setupCommentRoutes(api)
+
+		// 设置标签相关路由
+		setupTagRoutes(api)
+
+		// 设置分类相关路由
+		setupCategoryRoutes(api)
+
+		// 设置文章系列相关路由
+		setupSeriesRoutes(api)
+
+		// 设置搜索路由
+		setupSearchRoutes(api)
+
+		// 设置管理员相关路由
+		setupAdminRoutes(api)
 	}
 }
\ No newline at end of file
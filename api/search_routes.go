@@ -0,0 +1,14 @@
+package api
+
+import (
+	"blog-backend/controller"
+	"blog-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupSearchRoutes 配置搜索路由
+func setupSearchRoutes(api *gin.RouterGroup) {
+	// 全文搜索（无需认证，登录用户的结果中包含自己的草稿）
+	api.GET("/search", middleware.OptionalAuthMiddleware(), controller.Search)
+}
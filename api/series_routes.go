@@ -0,0 +1,31 @@
+package api
+
+import (
+	"blog-backend/controller"
+	"blog-backend/middleware"
+	"blog-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupSeriesRoutes 配置文章系列相关路由
+func setupSeriesRoutes(api *gin.RouterGroup) {
+	series := api.Group("/series")
+	{
+		// 获取系列详情（无需认证）
+		series.GET("/:id", middleware.OptionalAuthMiddleware(), controller.GetSeries)
+		series.GET("/by-slug/:slug", middleware.OptionalAuthMiddleware(), controller.GetSeriesBySlug)
+
+		// 管理系列（需要认证）
+		authSeries := series.Group("")
+		authSeries.Use(middleware.AuthMiddleware(), middleware.RequireScope(services.ScopePostsWrite))
+		{
+			authSeries.POST("", controller.CreateSeries)
+			authSeries.PUT("/:id", controller.UpdateSeries)
+			authSeries.DELETE("/:id", controller.DeleteSeries)
+			authSeries.POST("/:id/posts", controller.AddSeriesPost)
+			authSeries.PUT("/:id/posts/order", controller.ReorderSeriesPosts)
+			authSeries.DELETE("/:id/posts/:postId", controller.RemoveSeriesPost)
+		}
+	}
+}
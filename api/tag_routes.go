@@ -0,0 +1,18 @@
+package api
+
+import (
+	"blog-backend/controller"
+	"blog-backend/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupTagRoutes 配置标签相关路由
+func setupTagRoutes(api *gin.RouterGroup) {
+	tags := api.Group("/tags")
+	{
+		// 获取标签列表和标签下的文章（无需认证）
+		tags.GET("", controller.GetTags)
+		tags.GET("/:slug/posts", middleware.OptionalAuthMiddleware(), controller.GetTagPosts)
+	}
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"blog-backend/middleware"
 	"blog-backend/controller"
+	"blog-backend/services"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,11 +13,64 @@ func setupUserRoutes(api *gin.RouterGroup) {
 	// 用户相关路由（无需认证）
 	api.POST("/auth/register", controller.Register)
 	api.POST("/auth/login", controller.Login)
+	api.POST("/auth/login/mfa", controller.LoginMFA)
+	api.POST("/auth/refresh", controller.RefreshToken)
+	api.POST("/auth/logout", controller.Logout)
+	api.POST("/auth/password/forgot", controller.ForgotPassword)
+	api.POST("/auth/password/reset", controller.ResetPassword)
+	api.GET("/auth/verify", controller.VerifyEmail)
+	api.POST("/auth/deletion/confirm", controller.ConfirmAccountDeletion)
+	api.POST("/auth/verify/resend", middleware.AuthMiddleware(), middleware.RequireSession(), controller.ResendVerification)
+
+	// 外部OpenID Connect登录（无需认证）
+	api.GET("/auth/oidc/providers", controller.ListOIDCProviders)
+	api.GET("/auth/oidc/:provider/login", controller.OIDCLogin)
+	api.GET("/auth/oidc/:provider/callback", controller.OIDCCallback)
+
+	// 公开的用户资料（无需认证）
+	users := api.Group("/users")
+	{
+		users.GET("/:username", controller.GetPublicProfile)
+		users.GET("/:username/posts", middleware.OptionalAuthMiddleware(), controller.GetUserPosts)
+	}
 
 	// 用户相关路由（需要认证）
 	user := api.Group("/user")
 	user.Use(middleware.AuthMiddleware())
 	{
-		user.GET("/profile", controller.GetProfile)
+		user.GET("/profile", middleware.RequireScope(services.ScopeProfileRead), controller.GetProfile)
+		user.GET("/posts/scheduled", middleware.RequireScope(services.ScopePostsWrite), controller.GetScheduledPosts)
+	}
+
+	// 账户安全相关路由（只允许登录会话，不接受个人访问令牌）
+	account := user.Group("")
+	account.Use(middleware.RequireSession())
+	{
+		// 个人资料、密码和邮箱
+		account.PUT("/profile", controller.UpdateProfile)
+		account.PATCH("/profile", controller.UpdateProfile)
+		account.POST("/password", controller.ChangePassword)
+		account.POST("/email", controller.ChangeEmail)
+
+		// 个人数据导出和账户注销
+		account.GET("/export", controller.ExportAccount)
+		account.DELETE("", controller.DeleteAccount)
+		account.POST("/deletion/cancel", controller.CancelAccountDeletion)
+
+		// 外部登录身份
+		account.GET("/identities", controller.ListIdentities)
+		account.POST("/identities/:provider", controller.LinkIdentity)
+		account.DELETE("/identities/:id", controller.UnlinkIdentity)
+
+		// 两步验证
+		account.POST("/2fa/setup", controller.SetupTOTP)
+		account.POST("/2fa/enable", controller.EnableTOTP)
+		account.POST("/2fa/disable", controller.DisableTOTP)
+		account.POST("/2fa/recovery-codes", controller.RegenerateRecoveryCodes)
+
+		// 个人访问令牌
+		account.GET("/tokens", controller.ListAccessTokens)
+		account.POST("/tokens", controller.CreateAccessToken)
+		account.DELETE("/tokens/:id", controller.RevokeAccessToken)
 	}
-}
\ No newline at end of file
+}
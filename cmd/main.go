@@ -3,7 +3,10 @@ package main
 import (
 	"blog-backend/api"
 	"blog-backend/config"
+	"blog-backend/services"
 	"blog-backend/utils"
+	"context"
+	"log"
 	"net/http"
 	"time"
 
@@ -19,16 +22,41 @@ func main() {
 	// 初始化数据库
 	config.InitDB()
 
+	// 提升配置的初始管理员
+	promoted, err := services.PromoteBootstrapAdmins()
+	if err != nil {
+		log.Fatal("Failed to promote bootstrap admins:", err)
+	}
+	if promoted > 0 {
+		utils.Info("Promoted %d bootstrap admin(s)", promoted)
+	}
+
+	// 加载JWT签名密钥，配置错误时拒绝启动
+	if err := services.ReloadKeyRing(); err != nil {
+		log.Fatal("Failed to load JWT signing keys:", err)
+	}
+	if err := services.CheckCursorSecret(); err != nil {
+		log.Fatal("Invalid pagination config:", err)
+	}
+
+	// 启动后台任务：定时发布文章、删除注销冷静期已结束的账户
+	startScheduler()
+
 	// 设置Gin模式
 	gin.SetMode(gin.ReleaseMode)
 
 	// 创建Gin引擎
 	r := gin.Default()
 
+	// 设置可信代理，配置错误时拒绝启动
+	if err := api.SetupTrustedProxies(r); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+
 	// 配置CORS
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
@@ -55,3 +83,35 @@ func main() {
 		utils.Error("Failed to start server: %v", err)
 	}
 }
+
+// startScheduler 注册并启动后台任务
+func startScheduler() {
+	cfg := config.GetSchedulerConfig()
+	postService := services.NewPostService()
+	accountService := services.NewAccountService()
+
+	scheduler := services.NewScheduler()
+	scheduler.Register(services.Job{
+		Name:     "publish-scheduled-posts",
+		Interval: cfg.PublishInterval,
+		Run: func(now time.Time) error {
+			published, err := postService.PublishDuePosts(now)
+			if published > 0 {
+				utils.Info("Published %d scheduled posts", published)
+			}
+			return err
+		},
+	})
+	scheduler.Register(services.Job{
+		Name:     "purge-deleted-accounts",
+		Interval: cfg.AccountPurgeInterval,
+		Run: func(now time.Time) error {
+			purged, err := accountService.PurgeDueAccounts(now)
+			if purged > 0 {
+				utils.Info("Purged %d deleted accounts", purged)
+			}
+			return err
+		},
+	})
+	scheduler.Start(context.Background())
+}
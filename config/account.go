@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// AccountConfig 账户相关配置
+type AccountConfig struct {
+	BaseURL              string        // 站点地址，用于生成邮件中的链接
+	PasswordResetTTL     time.Duration // 密码重置令牌有效期
+	EmailVerificationTTL time.Duration // 邮箱验证令牌有效期
+	VerificationInterval time.Duration // 重新发送验证邮件的最小间隔
+	RequireVerifiedEmail bool          // 是否禁止未验证邮箱的用户发布文章和评论
+	TOTPIssuer           string        // 认证器应用中显示的发行方名称
+	MFATokenTTL          time.Duration // 两步验证登录挑战令牌有效期
+	DeletionGracePeriod  time.Duration // 申请注销后的冷静期，期间可以撤销
+	DeletionConfirmTTL   time.Duration // 通过邮件确认注销的令牌有效期
+	DeletionPolicy       string        // 注销策略：anonymize（内容转给占位用户）或 hard（连同内容一起删除）
+	DeletedUsername      string        // 匿名化后内容所属的占位用户名
+}
+
+// 账户注销策略
+const (
+	DeletionPolicyAnonymize = "anonymize"
+	DeletionPolicyHard      = "hard"
+)
+
+// GetAccountConfig 获取账户相关配置
+func GetAccountConfig() AccountConfig {
+	return AccountConfig{
+		BaseURL:              getEnv("APP_BASE_URL", "http://localhost:8000"),
+		PasswordResetTTL:     time.Hour,
+		EmailVerificationTTL: 24 * time.Hour,
+		VerificationInterval: time.Minute,
+		RequireVerifiedEmail: os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true",
+		TOTPIssuer:           getEnv("TOTP_ISSUER", "Blog"),
+		MFATokenTTL:          5 * time.Minute,
+		DeletionGracePeriod:  getDuration("ACCOUNT_DELETION_GRACE_PERIOD", 7*24*time.Hour),
+		DeletionConfirmTTL:   time.Hour,
+		DeletionPolicy:       getEnv("ACCOUNT_DELETION_POLICY", DeletionPolicyAnonymize),
+		DeletedUsername:      "deleted",
+	}
+}
+
+// getDuration 读取时长类型的环境变量（如 72h），格式错误时返回默认值
+func getDuration(key string, fallback time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value >= 0 {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,17 @@
+package config
+
+import "strconv"
+
+// CommentConfig 评论配置
+type CommentConfig struct {
+	MaxDepth int // 回复的最大层级，根评论为第0层，为0时不允许回复
+}
+
+// GetCommentConfig 获取评论配置
+func GetCommentConfig() CommentConfig {
+	maxDepth, err := strconv.Atoi(getEnv("COMMENT_MAX_DEPTH", "5"))
+	if err != nil || maxDepth < 0 {
+		maxDepth = 5
+	}
+	return CommentConfig{MaxDepth: maxDepth}
+}
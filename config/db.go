@@ -2,21 +2,30 @@ package config
 
 import (
 	"blog-backend/models"
+	"blog-backend/utils"
+	"fmt"
 	"log"
 
-	"gorm.io/driver/sqlite"
+	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
 var DB *gorm.DB
 
+// SQLiteDialector 打开SQLite数据库，应用和测试使用相同的连接参数
+// 使用纯Go实现的SQLite，所有构建都带有FTS5，不依赖构建标签和CGO
+// 时间按 "2006-01-02 15:04:05.999999999-07:00" 格式存储，保证按字符串比较时间的查询正确
+func SQLiteDialector(path string) gorm.Dialector {
+	return sqlite.Open(path + "?_time_format=sqlite")
+}
+
 // InitDB 初始化数据库连接并进行自动迁移
 func InitDB() {
 	var err error
 	
 	// 连接SQLite数据库
-	DB, err = gorm.Open(sqlite.Open("blog.db"), &gorm.Config{
+	DB, err = gorm.Open(SQLiteDialector("blog.db"), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 	
@@ -27,20 +36,160 @@ func InitDB() {
 	log.Println("Database connected successfully")
 	
 	// 自动迁移数据库表结构
-	err = DB.AutoMigrate(
+	if err := MigrateDB(DB); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+	
+	log.Println("Database migration completed")
+}
+
+// MigrateDB 对给定的数据库连接执行自动迁移
+func MigrateDB(db *gorm.DB) error {
+	// 引入邮箱验证之前注册的用户视为已验证
+	backfillEmailVerified := db.Migrator().HasTable(&models.User{}) &&
+		!db.Migrator().HasColumn(&models.User{}, "EmailVerified")
+	// 引入占位标记之前按用户名识别占位用户，注册时该用户名一直是保留的
+	backfillPlaceholder := db.Migrator().HasTable(&models.User{}) &&
+		!db.Migrator().HasColumn(&models.User{}, "Placeholder")
+
+	err := db.AutoMigrate(
 		&models.User{},
 		&models.Post{},
 		&models.Comment{},
+		&models.PostSlug{},
+		&models.PostRevision{},
+		&models.Tag{},
+		&models.Category{},
+		&models.Series{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.PasswordResetToken{},
+		&models.EmailVerificationToken{},
+		&models.AccountDeletionToken{},
+		&models.RecoveryCode{},
+		&models.PersonalAccessToken{},
+		&models.LoginAttempt{},
+		&models.UserIdentity{},
+		&models.OAuthState{},
+		&models.JobLease{},
 	)
-	
 	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
+		return err
 	}
-	
-	log.Println("Database migration completed")
+
+	if backfillEmailVerified {
+		err = db.Exec("UPDATE users SET email_verified = ?, email_verified_at = created_at", true).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if backfillPlaceholder {
+		username := GetAccountConfig().DeletedUsername
+		err = db.Exec("UPDATE users SET placeholder = ? WHERE username = ? AND email = ?", true, username, username+"@users.invalid").Error
+		if err != nil {
+			return err
+		}
+	}
+
+	// 引入文章状态之前的文章默认为已发布，以创建时间作为发布时间
+	err = db.Exec("UPDATE posts SET published_at = created_at WHERE status = ? AND published_at IS NULL", models.PostStatusPublished).Error
+	if err != nil {
+		return err
+	}
+
+	if err := backfillPostSlugs(db); err != nil {
+		return err
+	}
+	if err := renderStaleContent(db); err != nil {
+		return err
+	}
+	if err := backfillCommentPaths(db); err != nil {
+		return err
+	}
+	return createSearchIndex(db)
+}
+
+// backfillCommentPaths 引入回复之前的评论都是根评论，以自身ID作为路径
+func backfillCommentPaths(db *gorm.DB) error {
+	var comments []models.Comment
+	return db.Unscoped().Select("id").Where("path IS NULL OR path = ''").
+		FindInBatches(&comments, 100, func(tx *gorm.DB, batch int) error {
+			for _, comment := range comments {
+				comment.SetPath(nil)
+				err := tx.Unscoped().Model(&models.Comment{}).Where("id = ?", comment.ID).
+					UpdateColumns(map[string]interface{}{"path": comment.Path, "depth": 0}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// backfillPostSlugs 为引入别名之前的文章生成别名，追加文章ID保证唯一
+func backfillPostSlugs(db *gorm.DB) error {
+	var posts []models.Post
+	if err := db.Unscoped().Where("slug = ? OR slug IS NULL", "").Find(&posts).Error; err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		base := utils.Slugify(post.Title)
+		if base == "" {
+			base = "post"
+		}
+		slug := fmt.Sprintf("%s-%d", base, post.ID)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&models.PostSlug{PostID: post.ID, Slug: slug}).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Model(&post).UpdateColumn("slug", slug).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderStaleContent 重新渲染缓存HTML过期（渲染规则版本较旧）的文章和评论
+func renderStaleContent(db *gorm.DB) error {
+	var posts []models.Post
+	err := db.Unscoped().Select("id", "content").Where("render_version < ?", utils.MarkdownRenderVersion).
+		FindInBatches(&posts, 100, func(tx *gorm.DB, batch int) error {
+			for _, post := range posts {
+				err := tx.Unscoped().Model(&models.Post{}).Where("id = ?", post.ID).UpdateColumns(map[string]interface{}{
+					"content_html":   utils.RenderMarkdown(post.Content),
+					"render_version": utils.MarkdownRenderVersion,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	var comments []models.Comment
+	return db.Unscoped().Select("id", "content").Where("render_version < ?", utils.MarkdownRenderVersion).
+		FindInBatches(&comments, 100, func(tx *gorm.DB, batch int) error {
+			for _, comment := range comments {
+				err := tx.Unscoped().Model(&models.Comment{}).Where("id = ?", comment.ID).UpdateColumns(map[string]interface{}{
+					"content_html":   utils.RenderCommentMarkdown(comment.Content),
+					"render_version": utils.MarkdownRenderVersion,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
 }
 
 // GetDB 获取数据库连接实例
 func GetDB() *gorm.DB {
 	return DB
-}
\ No newline at end of file
+}
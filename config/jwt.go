@@ -2,16 +2,25 @@ package config
 
 import "time"
 
+// InsecureJWTSecret 早期版本的默认HMAC密钥，已公开，配置为该值时拒绝启动
+const InsecureJWTSecret = "your_secret_key_change_in_production"
+
 // JWTConfig JWT配置
 type JWTConfig struct {
-	SecretKey string
-	ExpiresIn time.Duration
+	SecretKey        string        // HMAC密钥，未配置签名密钥目录时使用
+	KeysDir          string        // 签名密钥目录，每个 <kid>.pem 文件是一把密钥
+	ActiveKeyID      string        // 用于签发新令牌的密钥ID，其余密钥只用于验证
+	ExpiresIn        time.Duration // 访问令牌有效期
+	RefreshExpiresIn time.Duration // 刷新令牌有效期
 }
 
 // GetJWTConfig 获取JWT配置
 func GetJWTConfig() JWTConfig {
 	return JWTConfig{
-		SecretKey: "your_secret_key_change_in_production",
-		ExpiresIn: 24 * time.Hour, // 24小时过期
+		SecretKey:        getEnv("JWT_SECRET", ""),
+		KeysDir:          getEnv("JWT_KEYS_DIR", ""),
+		ActiveKeyID:      getEnv("JWT_ACTIVE_KID", ""),
+		ExpiresIn:        15 * time.Minute,   // 访问令牌短期有效，过期后使用刷新令牌续期
+		RefreshExpiresIn: 7 * 24 * time.Hour, // 刷新令牌7天过期
 	}
-}
\ No newline at end of file
+}
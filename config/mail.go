@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// MailConfig 邮件发送配置
+type MailConfig struct {
+	Driver    string // smtp 或 outbox（写入本地目录，开发和测试环境使用）
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	From      string
+	OutboxDir string
+}
+
+// GetMailConfig 获取邮件发送配置
+func GetMailConfig() MailConfig {
+	port, err := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+	if err != nil {
+		port = 587
+	}
+
+	return MailConfig{
+		Driver:    getEnv("MAIL_DRIVER", "outbox"),
+		Host:      os.Getenv("SMTP_HOST"),
+		Port:      port,
+		Username:  os.Getenv("SMTP_USERNAME"),
+		Password:  os.Getenv("SMTP_PASSWORD"),
+		From:      getEnv("MAIL_FROM", "no-reply@blog.local"),
+		OutboxDir: getEnv("MAIL_OUTBOX_DIR", "outbox"),
+	}
+}
+
+// getEnv 读取环境变量，未设置时返回默认值
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// OIDCProvider 外部OpenID Connect登录提供方配置
+type OIDCProvider struct {
+	Name         string // 提供方名称，用于路由 /auth/oidc/:provider
+	Issuer       string // 发行方地址，需与提供方声明的 issuer 完全一致，从 <Issuer>/.well-known/openid-configuration 读取端点
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	RedirectURL  string // 授权回调地址，需要在提供方处登记
+}
+
+// GetOIDCProviders 获取已配置的OIDC提供方
+// OIDC_PROVIDERS 为逗号分隔的名称列表，每个提供方通过 OIDC_<NAME>_ISSUER、
+// OIDC_<NAME>_CLIENT_ID、OIDC_<NAME>_CLIENT_SECRET、OIDC_<NAME>_SCOPES、
+// OIDC_<NAME>_REDIRECT_URL 配置，缺少发行方或客户端ID的提供方会被忽略
+func GetOIDCProviders() map[string]OIDCProvider {
+	providers := make(map[string]OIDCProvider)
+	baseURL := GetAccountConfig().BaseURL
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProvider{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", baseURL+"/api/v1/auth/oidc/"+name+"/callback"),
+		}
+		if provider.Issuer == "" || provider.ClientID == "" {
+			continue
+		}
+		providers[name] = provider
+	}
+
+	return providers
+}
@@ -0,0 +1,13 @@
+package config
+
+// PaginationConfig 分页配置
+type PaginationConfig struct {
+	CursorSecret string // 分页游标的HMAC签名密钥，必须配置且不能与JWT_SECRET相同
+}
+
+// GetPaginationConfig 获取分页配置
+func GetPaginationConfig() PaginationConfig {
+	return PaginationConfig{
+		CursorSecret: getEnv("CURSOR_SECRET", ""),
+	}
+}
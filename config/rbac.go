@@ -0,0 +1,28 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// RBACConfig 角色权限配置
+type RBACConfig struct {
+	DefaultRole     string   // 新注册用户的默认角色
+	BootstrapAdmins []string // 启动时授予管理员角色的已注册用户名
+}
+
+// GetRBACConfig 获取角色权限配置
+// 初始管理员通过环境变量 BLOG_ADMIN_USERNAMES（逗号分隔）指定
+func GetRBACConfig() RBACConfig {
+	var admins []string
+	for _, name := range strings.Split(os.Getenv("BLOG_ADMIN_USERNAMES"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			admins = append(admins, name)
+		}
+	}
+
+	return RBACConfig{
+		DefaultRole:     "author",
+		BootstrapAdmins: admins,
+	}
+}
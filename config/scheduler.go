@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+// SchedulerConfig 后台任务配置
+type SchedulerConfig struct {
+	PublishInterval      time.Duration // 检查定时发布文章的间隔
+	AccountPurgeInterval time.Duration // 清理到期注销账户的间隔
+}
+
+// GetSchedulerConfig 获取后台任务配置
+func GetSchedulerConfig() SchedulerConfig {
+	return SchedulerConfig{
+		PublishInterval:      getDuration("SCHEDULED_PUBLISH_INTERVAL", time.Minute),
+		AccountPurgeInterval: time.Hour,
+	}
+}
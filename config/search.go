@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// SearchIndexTable 全文搜索使用的FTS5虚拟表，rowid为 2*文章ID 或 2*评论ID+1
+const SearchIndexTable = "search_index"
+
+// 搜索引擎
+const (
+	SearchEngineFTS5 = "fts5" // FTS5全文索引，按相关度排序（默认）
+	SearchEngineLike = "like" // LIKE查询，不建索引，按时间倒序
+)
+
+// SearchConfig 搜索配置
+type SearchConfig struct {
+	Engine string // fts5 或 like，只有明确配置时才使用 like
+}
+
+// GetSearchConfig 获取搜索配置
+func GetSearchConfig() SearchConfig {
+	return SearchConfig{Engine: getEnv("SEARCH_ENGINE", SearchEngineFTS5)}
+}
+
+// createSearchIndex 创建FTS5全文索引，新建时导入已有的文章和评论；索引无法创建时返回错误，服务不会启动
+// 使用LIKE查询时删除已有的索引，之后切换回FTS5时重新导入，避免索引遗漏期间的修改
+func createSearchIndex(db *gorm.DB) error {
+	switch engine := GetSearchConfig().Engine; engine {
+	case SearchEngineLike:
+		return db.Exec("DROP TABLE IF EXISTS " + SearchIndexTable).Error
+	case SearchEngineFTS5:
+	default:
+		return fmt.Errorf("unknown search engine %q", engine)
+	}
+	if db.Migrator().HasTable(SearchIndexTable) {
+		return nil
+	}
+
+	err := db.Exec("CREATE VIRTUAL TABLE " + SearchIndexTable +
+		" USING fts5(title, body, prefix='2 3', tokenize='unicode61 remove_diacritics 2')").Error
+	if err != nil {
+		return fmt.Errorf("failed to create search index: %w", err)
+	}
+
+	// 去掉用于标记高亮位置的控制字符
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("INSERT INTO " + SearchIndexTable + " (rowid, title, body) " +
+			"SELECT id * 2, replace(replace(title, char(2), ''), char(3), ''), replace(replace(content, char(2), ''), char(3), '') " +
+			"FROM posts WHERE deleted_at IS NULL").Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec("INSERT INTO " + SearchIndexTable + " (rowid, title, body) " +
+			"SELECT id * 2 + 1, '', replace(replace(content, char(2), ''), char(3), '') " +
+			"FROM comments WHERE deleted_at IS NULL AND NOT deleted").Error
+	})
+}
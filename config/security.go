@@ -0,0 +1,33 @@
+package config
+
+import "time"
+
+// LoginProtectionConfig 登录防暴力破解配置
+type LoginProtectionConfig struct {
+	Store           string        // 失败记录存储：memory（单实例）或 database（多实例共享）
+	Window          time.Duration // 距上次失败超过该时长后失败计数重新开始
+	BaseDelay       time.Duration // 超过免等待次数后的首次等待时长，之后每次失败翻倍
+	MaxDelay        time.Duration // 指数退避的最长等待时长
+	LockoutDuration time.Duration // 达到锁定阈值后的锁定时长
+
+	UserFreeAttempts     int // 同一用户名允许连续失败而无需等待的次数
+	UserLockoutThreshold int // 同一用户名连续失败达到该次数后锁定
+	IPFreeAttempts       int // 同一IP允许连续失败而无需等待的次数
+	IPLockoutThreshold   int // 同一IP连续失败达到该次数后锁定
+}
+
+// GetLoginProtectionConfig 获取登录防暴力破解配置
+func GetLoginProtectionConfig() LoginProtectionConfig {
+	return LoginProtectionConfig{
+		Store:           getEnv("LOGIN_ATTEMPT_STORE", "memory"),
+		Window:          time.Hour,
+		BaseDelay:       time.Second,
+		MaxDelay:        5 * time.Minute,
+		LockoutDuration: 15 * time.Minute,
+
+		UserFreeAttempts:     3,
+		UserLockoutThreshold: 10,
+		IPFreeAttempts:       10,
+		IPLockoutThreshold:   50,
+	}
+}
@@ -0,0 +1,21 @@
+package config
+
+import "strings"
+
+// ServerConfig HTTP服务配置
+type ServerConfig struct {
+	// TrustedProxies 可信的反向代理地址（IP或CIDR），只有来自这些地址的请求才会读取 X-Forwarded-For；
+	// 默认为空，不信任任何代理，客户端IP取连接的远端地址
+	TrustedProxies []string
+}
+
+// GetServerConfig 获取HTTP服务配置，TRUSTED_PROXIES 为逗号分隔的地址列表
+func GetServerConfig() ServerConfig {
+	var proxies []string
+	for _, proxy := range strings.Split(getEnv("TRUSTED_PROXIES", ""), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return ServerConfig{TrustedProxies: proxies}
+}
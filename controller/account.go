@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"blog-backend/models"
+	"blog-backend/services"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 创建服务实例
+var accountService = services.NewAccountService()
+
+// ExportAccount 导出个人数据，默认为ZIP压缩包，format=json时返回单个JSON文件
+func ExportAccount(c *gin.Context) {
+	format := c.DefaultQuery("format", "zip")
+	if format != "zip" && format != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Format must be zip or json",
+			"error":   "Invalid format",
+		})
+		return
+	}
+
+	export, err := accountService.Export(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("%s-export-%s.%s", export.Profile.Username, export.ExportedAt.Format("20060102"), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+
+	if format == "json" {
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+	if err := accountService.WriteExportZip(c.Writer, export); err != nil {
+		c.Error(err)
+	}
+}
+
+// DeleteAccount 申请注销账户，冷静期结束后删除
+func DeleteAccount(c *gin.Context) {
+	// 没有请求体时视为空请求，没有本地密码的账户通过邮件确认注销
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	// 没有提交密码时通过邮件确认，外部身份创建的账户没有可用的本地密码
+	if req.Password == "" {
+		if err := accountService.RequestDeletionConfirmation(c.GetUint("userID")); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{
+			"message": "Confirmation email sent",
+		})
+		return
+	}
+
+	dueAt, err := accountService.ScheduleDeletion(c.GetUint("userID"), req.Password)
+	if err != nil {
+		var rateLimitErr *services.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			respondTooManyRequests(c, rateLimitErr)
+			return
+		}
+		switch err.Error() {
+		case "invalid current password":
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": "Current password is incorrect",
+				"error":   "Invalid current password",
+			})
+		case "cannot remove the last admin":
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Cannot remove the last admin",
+				"error":   "Cannot remove the last admin",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":         "Account scheduled for deletion",
+		"deletion_due_at": dueAt.Format(time.RFC3339),
+	})
+}
+
+// ConfirmAccountDeletion 使用邮件中的令牌确认注销
+func ConfirmAccountDeletion(c *gin.Context) {
+	var req models.ConfirmDeletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	dueAt, err := accountService.ConfirmDeletion(req.Token)
+	if err != nil {
+		switch err.Error() {
+		case "invalid or expired token":
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid or expired token",
+				"error":   "Invalid or expired token",
+			})
+		case "cannot remove the last admin":
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Cannot remove the last admin",
+				"error":   "Cannot remove the last admin",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":         "Account scheduled for deletion",
+		"deletion_due_at": dueAt.Format(time.RFC3339),
+	})
+}
+
+// CancelAccountDeletion 撤销注销申请
+func CancelAccountDeletion(c *gin.Context) {
+	if err := accountService.CancelDeletion(c.GetUint("userID")); err != nil {
+		if err.Error() == "deletion not scheduled" {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Account deletion is not scheduled",
+				"error":   "Deletion not scheduled",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Account deletion cancelled",
+	})
+}
@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"blog-backend/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListUsers 获取用户列表（管理员）
+func ListUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	users, total, err := userService.ListUsers(page, pageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to fetch users",
+			"error":   "Failed to fetch users",
+		})
+		return
+	}
+
+	totalPages := (total + int64(pageSize) - 1) / int64(pageSize)
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+		"pagination": gin.H{
+			"page":        page,
+			"page_size":   pageSize,
+			"total":       total,
+			"total_pages": totalPages,
+		},
+	})
+}
+
+// GrantRole 授予用户角色（管理员）
+func GrantRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid user ID",
+			"error":   "Invalid user ID",
+		})
+		return
+	}
+
+	var req models.RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	user, err := userService.SetRole(uint(id), req.Role)
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role granted successfully",
+		"user":    user,
+	})
+}
+
+// RevokeRole 撤销用户角色，恢复为默认角色（管理员）
+func RevokeRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid user ID",
+			"error":   "Invalid user ID",
+		})
+		return
+	}
+
+	user, err := userService.RevokeRole(uint(id))
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Role revoked successfully",
+		"user":    user,
+	})
+}
+
+// UnlockUser 解除用户的登录锁定（管理员），可同时指定需要解锁的IP
+func UnlockUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid user ID",
+			"error":   "Invalid user ID",
+		})
+		return
+	}
+
+	// 请求体可以为空
+	var req models.UnlockRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid request data",
+				"error":   "Invalid request data",
+			})
+			return
+		}
+	}
+
+	if err := userService.UnlockLogin(uint(id), req.IP); err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User unlocked successfully",
+	})
+}
+
+// respondRoleError 将角色变更错误转换为HTTP响应
+func respondRoleError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "user not found":
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "User not found",
+			"error":   "User not found",
+		})
+	case "invalid role":
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid role",
+			"error":   "Invalid role",
+		})
+	case "cannot remove the last admin":
+		c.JSON(http.StatusConflict, gin.H{
+			"message": "Cannot remove the last admin",
+			"error":   "Cannot remove the last admin",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"error":   err.Error(),
+		})
+	}
+}
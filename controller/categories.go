@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"blog-backend/models"
+	"blog-backend/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 创建服务实例
+var categoryService = services.NewCategoryService()
+
+// GetCategories 获取分类树
+func GetCategories(c *gin.Context) {
+	categories, err := categoryService.GetCategoryTree()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to fetch categories",
+			"error":   "Failed to fetch categories",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"categories": categories,
+	})
+}
+
+// GetCategory 获取单个分类及其子分类
+func GetCategory(c *gin.Context) {
+	category, err := categoryService.GetCategoryBySlug(c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "Category not found",
+			"error":   "Category not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"category": category,
+	})
+}
+
+// GetCategoryPosts 获取分类及其子孙分类下的文章列表
+func GetCategoryPosts(c *gin.Context) {
+	category, err := categoryService.GetCategoryBySlug(c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "Category not found",
+			"error":   "Category not found",
+		})
+		return
+	}
+
+	respondPostList(c, services.PostListOptions{
+		ViewerID: c.GetUint("userID"),
+		Category: category.Slug,
+	}, gin.H{"category": category})
+}
+
+// CreateCategory 创建分类（编辑和管理员）
+func CreateCategory(c *gin.Context) {
+	var req models.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	category, err := categoryService.CreateCategory(&req)
+	if err != nil {
+		respondCategoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Category created successfully",
+		"category": category,
+	})
+}
+
+// UpdateCategory 更新分类（编辑和管理员）
+func UpdateCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid category ID",
+			"error":   "Invalid category ID",
+		})
+		return
+	}
+
+	var req models.CategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	category, err := categoryService.UpdateCategory(uint(id), &req)
+	if err != nil {
+		respondCategoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Category updated successfully",
+		"category": category,
+	})
+}
+
+// DeleteCategory 删除分类（编辑和管理员），仍有文章时需要通过 reassign_to 参数指定文章转入的分类
+func DeleteCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid category ID",
+			"error":   "Invalid category ID",
+		})
+		return
+	}
+
+	var reassignTo uint64
+	if value := c.Query("reassign_to"); value != "" {
+		if reassignTo, err = strconv.ParseUint(value, 10, 32); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid category ID",
+				"error":   "Invalid category ID",
+			})
+			return
+		}
+	}
+
+	if err := categoryService.DeleteCategory(uint(id), uint(reassignTo)); err != nil {
+		respondCategoryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Category deleted successfully",
+	})
+}
+
+// respondCategoryError 返回分类管理的错误响应
+func respondCategoryError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "category not found":
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "Category not found",
+			"error":   "Category not found",
+		})
+	case "invalid slug":
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Slug may only contain lowercase letters, digits and single hyphens",
+			"error":   "Invalid slug",
+		})
+	case "slug taken":
+		c.JSON(http.StatusConflict, gin.H{
+			"message": "Slug is already used by another category",
+			"error":   "Slug taken",
+		})
+	case "parent not found":
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Parent category not found",
+			"error":   "Parent not found",
+		})
+	case "invalid parent":
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "A category cannot be moved under itself or its descendants",
+			"error":   "Invalid parent",
+		})
+	case "category in use":
+		c.JSON(http.StatusConflict, gin.H{
+			"message": "Category still has posts, specify reassign_to to move them",
+			"error":   "Category in use",
+		})
+	case "invalid reassignment":
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Posts must be reassigned to another existing category",
+			"error":   "Invalid reassignment",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to save category",
+			"error":   "Failed to save category",
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"blog-backend/middleware"
 	"blog-backend/models"
 	"blog-backend/services"
 	"net/http"
@@ -46,13 +47,38 @@ func CreateComment(c *gin.Context) {
 	}
 
 	// 调用服务层创建评论
-	comment, err := commentService.CreateComment(req.Content, userID.(uint), uint(postID))
+	comment, err := commentService.CreateComment(req.Content, userID.(uint), uint(postID), req.ParentID)
 	if err != nil {
 		if err.Error() == "post not found" {
 			c.JSON(http.StatusNotFound, gin.H{
 				"message": "Post not found",
 				"error":   "Post not found",
 			})
+		} else if err.Error() == "permission denied" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "You don't have permission to comment",
+				"error":   "You don't have permission to comment",
+			})
+		} else if err.Error() == "email not verified" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Please verify your email address before commenting",
+				"error":   "Please verify your email address before commenting",
+			})
+		} else if err.Error() == "parent not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "Parent comment not found",
+				"error":   "Parent comment not found",
+			})
+		} else if err.Error() == "max depth exceeded" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Replies are nested too deeply",
+				"error":   "Max depth exceeded",
+			})
+		} else if err.Error() == "comments closed" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Comments are closed for archived posts",
+				"error":   "Comments are closed",
+			})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"message": err.Error(),
@@ -72,7 +98,7 @@ func CreateComment(c *gin.Context) {
 // GetComments 获取文章评论列表
 func GetComments(c *gin.Context) {
 	// 获取文章ID
-	postIDStr := c.Param("postId")
+	postIDStr := c.Param("id")
 	postID, err := strconv.ParseUint(postIDStr, 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -82,27 +108,90 @@ func GetComments(c *gin.Context) {
 		return
 	}
 
+	format, ok := contentFormat(c)
+	if !ok {
+		return
+	}
+	opts := services.CommentListOptions{
+		Order:    c.Query("order"),
+		View:     c.Query("view"),
+		ViewerID: c.GetUint("userID"),
+	}
+
+	// 带有 cursor 或 limit 参数时使用游标分页
+	if cursor, ok := cursorParams(c); ok {
+		comments, cursors, err := commentService.GetCommentsByCursor(uint(postID), opts, cursor)
+		if err != nil {
+			respondCommentListError(c, err)
+			return
+		}
+
+		formatComments(comments, format)
+		c.JSON(http.StatusOK, gin.H{
+			"comments": comments,
+			"pagination": gin.H{
+				"limit":       cursor.Limit,
+				"next_cursor": cursors.Next,
+				"prev_cursor": cursors.Prev,
+			},
+		})
+		return
+	}
+
 	// 调用服务层获取评论列表
-	comments, _, err := commentService.GetComments(uint(postID))
+	opts.Page, opts.PageSize = pageParams(c)
+	comments, total, err := commentService.GetComments(uint(postID), opts)
 	if err != nil {
-		if err.Error() == "post not found" {
-			c.JSON(http.StatusNotFound, gin.H{
-				"message": "Post not found",
-				"error":   "Post not found",
-			})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"message": err.Error(),
-				"error":   err.Error(),
-			})
-		}
+		respondCommentListError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, comments)
+	formatComments(comments, format)
+	c.JSON(http.StatusOK, gin.H{
+		"comments": comments,
+		"pagination": gin.H{
+			"page":        opts.Page,
+			"page_size":   opts.PageSize,
+			"total":       total,
+			"total_pages": (total + int64(opts.PageSize) - 1) / int64(opts.PageSize),
+		},
+	})
+}
+
+// respondCommentListError 评论列表查询失败时按错误类型返回
+func respondCommentListError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "post not found":
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "Post not found",
+			"error":   "Post not found",
+		})
+	case "invalid order":
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid order",
+			"error":   "Invalid order",
+		})
+	case "invalid view":
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid view",
+			"error":   "Invalid view",
+		})
+	case "order not supported":
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Cursor pagination does not support thread order",
+			"error":   "Order not supported",
+		})
+	case "invalid cursor":
+		respondInvalidCursor(c)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"error":   err.Error(),
+		})
+	}
 }
 
-// UpdateComment 更新评论（只有评论作者可以更新）
+// UpdateComment 更新评论（评论作者或评论管理员可以更新）
 func UpdateComment(c *gin.Context) {
 	// 从上下文获取用户ID
 	userID, exists := c.Get("userID")
@@ -135,6 +224,11 @@ func UpdateComment(c *gin.Context) {
 		return
 	}
 
+	// 个人访问令牌需要与操作对象匹配的权限范围
+	if !checkCommentScope(c, uint(commentID), userID.(uint)) {
+		return
+	}
+
 	// 调用服务层更新评论
 	comment, err := commentService.UpdateComment(uint(commentID), req.Content, userID.(uint))
 	if err != nil {
@@ -163,7 +257,7 @@ func UpdateComment(c *gin.Context) {
 	})
 }
 
-// DeleteComment 删除评论（评论作者或文章作者可以删除）
+// DeleteComment 删除评论（评论作者、文章作者或评论管理员可以删除）
 func DeleteComment(c *gin.Context) {
 	// 从上下文获取用户ID
 	userID, exists := c.Get("userID")
@@ -186,6 +280,11 @@ func DeleteComment(c *gin.Context) {
 		return
 	}
 
+	// 个人访问令牌需要与操作对象匹配的权限范围
+	if !checkCommentScope(c, uint(commentID), userID.(uint)) {
+		return
+	}
+
 	// 调用服务层删除评论
 	err = commentService.DeleteComment(uint(commentID), userID.(uint))
 	if err != nil {
@@ -211,4 +310,34 @@ func DeleteComment(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Comment deleted successfully",
 	})
-}
\ No newline at end of file
+}
+
+// checkCommentScope 使用个人访问令牌时，操作自己的评论需要 comments:write，
+// 操作他人的评论属于管理行为，需要 comments:moderate
+func checkCommentScope(c *gin.Context, commentID, userID uint) bool {
+	if middleware.HasScope(c, services.ScopeCommentsWrite) && middleware.HasScope(c, services.ScopeCommentsModerate) {
+		return true
+	}
+
+	comment, err := commentService.GetCommentByID(commentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "Comment not found",
+			"error":   "Comment not found",
+		})
+		return false
+	}
+
+	scope := services.ScopeCommentsModerate
+	if comment.UserID == userID {
+		scope = services.ScopeCommentsWrite
+	}
+	if !middleware.HasScope(c, scope) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "Token scope insufficient",
+			"error":   "Token scope insufficient",
+		})
+		return false
+	}
+	return true
+}
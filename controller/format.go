@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"blog-backend/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 正文的返回格式
+const (
+	formatMarkdown = "markdown" // 只返回Markdown源文本
+	formatHTML     = "html"     // 只返回渲染后的HTML
+)
+
+// contentFormat 解析 format 查询参数，为空时同时返回源文本和HTML，参数无效时返回400
+func contentFormat(c *gin.Context) (string, bool) {
+	format := c.Query("format")
+	if format != "" && format != formatMarkdown && format != formatHTML {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid format",
+			"error":   "Invalid format",
+		})
+		return "", false
+	}
+	return format, true
+}
+
+// formatPost 按返回格式去掉文章及其评论中不需要的正文字段
+func formatPost(post *models.Post, format string) {
+	switch format {
+	case formatMarkdown:
+		post.ContentHTML = ""
+	case formatHTML:
+		post.Content = ""
+	}
+	formatComments(post.Comments, format)
+}
+
+// formatPosts 按返回格式处理文章列表
+func formatPosts(posts []models.Post, format string) {
+	for i := range posts {
+		formatPost(&posts[i], format)
+	}
+}
+
+// formatComments 按返回格式去掉评论（包括嵌套的回复）中不需要的正文字段
+func formatComments(comments []models.Comment, format string) {
+	for i := range comments {
+		switch format {
+		case formatMarkdown:
+			comments[i].ContentHTML = ""
+		case formatHTML:
+			comments[i].Content = ""
+		}
+		formatComments(comments[i].Replies, format)
+	}
+}
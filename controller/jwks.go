@@ -0,0 +1,16 @@
+package controller
+
+import (
+	"blog-backend/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS 获取用于验证访问令牌的公钥集合
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{
+		"keys": services.GetKeyRing().JWKS(),
+	})
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"blog-backend/models"
+	"blog-backend/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupTOTP 生成两步验证密钥和认证器扫码URI
+func SetupTOTP(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	setup, err := mfaService.Setup(userID.(uint))
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Scan the provisioning URI with your authenticator app, then confirm with a code",
+		"secret":           setup.Secret,
+		"provisioning_uri": setup.ProvisioningURI,
+	})
+}
+
+// EnableTOTP 使用验证码确认并启用两步验证
+func EnableTOTP(c *gin.Context) {
+	userID, req, ok := bindTOTPCode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := mfaService.Enable(userID, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTOTP 使用新的验证码关闭两步验证
+func DisableTOTP(c *gin.Context) {
+	userID, req, ok := bindTOTPCode(c)
+	if !ok {
+		return
+	}
+
+	if err := mfaService.Disable(userID, req.Code); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func RegenerateRecoveryCodes(c *gin.Context) {
+	userID, req, ok := bindTOTPCode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := mfaService.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Recovery codes regenerated",
+		"recovery_codes": codes,
+	})
+}
+
+// bindTOTPCode 获取当前用户ID并绑定验证码请求
+func bindTOTPCode(c *gin.Context) (uint, *models.TOTPCodeRequest, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+			"error":   "Unauthorized",
+		})
+		return 0, nil, false
+	}
+
+	var req models.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return 0, nil, false
+	}
+
+	return userID.(uint), &req, true
+}
+
+// respondMFAError 将两步验证错误转换为HTTP响应
+func respondMFAError(c *gin.Context, err error) {
+	var rateLimitErr *services.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		respondTooManyRequests(c, rateLimitErr)
+		return
+	}
+	switch err.Error() {
+	case "user not found":
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "User not found",
+			"error":   "User not found",
+		})
+	case "invalid code":
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid two-factor code",
+			"error":   "Invalid two-factor code",
+		})
+	case "two-factor authentication already enabled",
+		"two-factor authentication not enabled",
+		"two-factor authentication not set up":
+		c.JSON(http.StatusConflict, gin.H{
+			"message": err.Error(),
+			"error":   err.Error(),
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"error":   err.Error(),
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"blog-backend/config"
+	"blog-backend/services"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 创建服务实例
+var oidcService = services.NewOIDCService()
+
+// 保存授权请求浏览器绑定值的Cookie，只在回调路径下发送，有效期与授权状态一致
+const (
+	oidcBindingCookie = "oidc_binding"
+	oidcCookiePath    = "/api/v1/auth/oidc"
+	oidcCookieMaxAge  = 10 * 60
+)
+
+// setOIDCBinding 将授权请求绑定到当前浏览器；提供方的回调是跨站跳转，需要 SameSite=Lax
+func setOIDCBinding(c *gin.Context, binding string, maxAge int) {
+	secure := strings.HasPrefix(config.GetAccountConfig().BaseURL, "https://")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcBindingCookie, binding, maxAge, oidcCookiePath, "", secure, true)
+}
+
+// ListOIDCProviders 获取可用的外部登录提供方
+func ListOIDCProviders(c *gin.Context) {
+	names := []string{}
+	for name := range config.GetOIDCProviders() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	c.JSON(http.StatusOK, gin.H{
+		"providers": names,
+	})
+}
+
+// OIDCLogin 跳转到外部提供方进行登录
+func OIDCLogin(c *gin.Context) {
+	authURL, binding, err := oidcService.AuthorizationURL(c.Param("provider"), 0)
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+	setOIDCBinding(c, binding, oidcCookieMaxAge)
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback 外部提供方授权后的回调，登录或完成身份绑定
+func OIDCCallback(c *gin.Context) {
+	// 用户拒绝授权等情况下提供方会返回error参数
+	if errCode := c.Query("error"); errCode != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Authorization failed: " + errCode,
+			"error":   "Authorization failed",
+		})
+		return
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Code and state are required",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	binding, _ := c.Cookie(oidcBindingCookie)
+	setOIDCBinding(c, "", -1)
+	result, err := oidcService.HandleCallback(c.Param("provider"), code, state, binding)
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	if result.Linked {
+		c.JSON(http.StatusOK, gin.H{
+			"message":  "Identity linked successfully",
+			"identity": result.Identity,
+		})
+		return
+	}
+
+	respondLoginResult(c, result.Login)
+}
+
+// LinkIdentity 为当前用户绑定外部身份，返回需要跳转的授权地址
+func LinkIdentity(c *gin.Context) {
+	authURL, binding, err := oidcService.AuthorizationURL(c.Param("provider"), c.GetUint("userID"))
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+	setOIDCBinding(c, binding, oidcCookieMaxAge)
+
+	c.JSON(http.StatusOK, gin.H{
+		"authorization_url": authURL,
+	})
+}
+
+// ListIdentities 获取当前用户绑定的外部身份
+func ListIdentities(c *gin.Context) {
+	identities, err := oidcService.ListIdentities(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"identities": identities,
+	})
+}
+
+// UnlinkIdentity 解除绑定外部身份
+func UnlinkIdentity(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid identity ID",
+			"error":   "Invalid identity ID",
+		})
+		return
+	}
+
+	if err := oidcService.UnlinkIdentity(c.GetUint("userID"), uint(id)); err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Identity unlinked successfully",
+	})
+}
+
+// respondOIDCError 将外部登录错误转换为HTTP响应
+func respondOIDCError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "provider not found":
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "Login provider not found",
+			"error":   "Provider not found",
+		})
+	case "identity not found":
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "Identity not found",
+			"error":   "Identity not found",
+		})
+	case "invalid state", "invalid authorization code", "invalid id token", "unknown signing key", "email required":
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "External login failed: " + err.Error(),
+			"error":   "External login failed",
+		})
+	case "email already exists":
+		c.JSON(http.StatusConflict, gin.H{
+			"message": "An account with this email already exists, sign in and link the identity instead",
+			"error":   "Email already exists",
+		})
+	case "identity already linked":
+		c.JSON(http.StatusConflict, gin.H{
+			"message": "This identity is linked to another account",
+			"error":   "Identity already linked",
+		})
+	case "oidc provider error":
+		c.JSON(http.StatusBadGateway, gin.H{
+			"message": "Login provider is unavailable",
+			"error":   "Provider error",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"error":   err.Error(),
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"blog-backend/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageParams 解析页码分页参数
+func pageParams(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+	return page, pageSize
+}
+
+// cursorParams 请求中带有 cursor 或 limit 参数时使用游标分页
+func cursorParams(c *gin.Context) (services.CursorPage, bool) {
+	_, hasCursor := c.GetQuery("cursor")
+	_, hasLimit := c.GetQuery("limit")
+	if !hasCursor && !hasLimit {
+		return services.CursorPage{}, false
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+	return services.CursorPage{Cursor: c.Query("cursor"), Limit: limit}, true
+}
+
+// respondInvalidCursor 游标被篡改、来自其它列表或无法解析时返回400
+func respondInvalidCursor(c *gin.Context) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"message": "Invalid cursor",
+		"error":   "Invalid cursor",
+	})
+}
+
+// parseListTime 解析时间范围参数，支持 YYYY-MM-DD 和 RFC3339 格式；
+// 只有日期的结束时间包含当天全天
+func parseListTime(value string, end bool) (*time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if end {
+			t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	t = t.In(time.Local)
+	return &t, nil
+}
+
+// postListFilters 解析文章列表通用的筛选和排序参数，参数无效时返回400
+func postListFilters(c *gin.Context, opts *services.PostListOptions) bool {
+	invalid := func(message string) bool {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": message,
+			"error":   message,
+		})
+		return false
+	}
+
+	if from := c.Query("from"); from != "" {
+		t, err := parseListTime(from, false)
+		if err != nil {
+			return invalid("Invalid date")
+		}
+		opts.CreatedFrom = t
+	}
+	if to := c.Query("to"); to != "" {
+		t, err := parseListTime(to, true)
+		if err != nil {
+			return invalid("Invalid date")
+		}
+		opts.CreatedTo = t
+	}
+	if value := c.Query("has_comments"); value != "" {
+		hasComments, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid("Invalid has_comments")
+		}
+		opts.HasComments = &hasComments
+	}
+	opts.Sort = c.Query("sort")
+	opts.Order = c.Query("order")
+	return true
+}
+
+// respondPostListError 文章列表查询失败时按错误类型返回
+func respondPostListError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "invalid cursor":
+		respondInvalidCursor(c)
+	case "invalid sort":
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid sort",
+			"error":   "Invalid sort",
+		})
+	case "sort not supported":
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Cursor pagination only supports the default sort",
+			"error":   "Sort not supported",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to fetch posts",
+			"error":   "Failed to fetch posts",
+		})
+	}
+}
+
+// respondPostList 按页码或游标分页获取文章列表并返回，extra 为响应中附加的字段（如标签、分类）
+func respondPostList(c *gin.Context, opts services.PostListOptions, extra gin.H) {
+	format, ok := contentFormat(c)
+	if !ok {
+		return
+	}
+	if !postListFilters(c, &opts) {
+		return
+	}
+
+	response := gin.H{}
+	for key, value := range extra {
+		response[key] = value
+	}
+
+	if cursor, ok := cursorParams(c); ok {
+		posts, cursors, err := postService.GetPostsByCursor(opts, cursor)
+		if err != nil {
+			respondPostListError(c, err)
+			return
+		}
+
+		formatPosts(posts, format)
+		response["posts"] = posts
+		response["pagination"] = gin.H{
+			"limit":       cursor.Limit,
+			"next_cursor": cursors.Next,
+			"prev_cursor": cursors.Prev,
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	opts.Page, opts.PageSize = pageParams(c)
+	posts, total, err := postService.GetPosts(opts)
+	if err != nil {
+		respondPostListError(c, err)
+		return
+	}
+
+	formatPosts(posts, format)
+	response["posts"] = posts
+	response["pagination"] = gin.H{
+		"page":        opts.Page,
+		"page_size":   opts.PageSize,
+		"total":       total,
+		"total_pages": (total + int64(opts.PageSize) - 1) / int64(opts.PageSize),
+	}
+	c.JSON(http.StatusOK, response)
+}
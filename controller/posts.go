@@ -4,6 +4,7 @@ import (
 	"blog-backend/models"
 	"blog-backend/services"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -34,12 +35,49 @@ func CreatePost(c *gin.Context) {
 	}
 
 	// 调用服务层创建文章
-	post, err := postService.CreatePost(req.Title, req.Content, userID.(uint))
+	post, err := postService.CreatePost(&req, userID.(uint))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Failed to create post",
-			"error":   "Failed to create post",
-		})
+		if err.Error() == "permission denied" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "You don't have permission to create posts",
+				"error":   "You don't have permission to create posts",
+			})
+		} else if err.Error() == "email not verified" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Please verify your email address before posting",
+				"error":   "Please verify your email address before posting",
+			})
+		} else if err.Error() == "invalid slug" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Slug may only contain lowercase letters, digits and single hyphens",
+				"error":   "Invalid slug",
+			})
+		} else if err.Error() == "slug taken" {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Slug is already used by another post",
+				"error":   "Slug taken",
+			})
+		} else if err.Error() == "invalid tag" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Tag names must contain at least one letter or digit",
+				"error":   "Invalid tag",
+			})
+		} else if err.Error() == "category not found" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Category not found",
+				"error":   "Category not found",
+			})
+		} else if err.Error() == "invalid schedule" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Scheduled time must be in the future and the post must be a draft",
+				"error":   "Invalid schedule",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Failed to create post",
+				"error":   "Failed to create post",
+			})
+		}
 		return
 	}
 
@@ -50,41 +88,25 @@ func CreatePost(c *gin.Context) {
 	})
 }
 
-// GetPosts 获取文章列表
+// GetPosts 获取文章列表，支持页码分页（page、page_size）和游标分页（cursor、limit）
 func GetPosts(c *gin.Context) {
-	// 分页参数
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
-
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 10
-	}
-
-	// 调用服务层获取文章列表
-	posts, total, err := postService.GetPosts(page, pageSize)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Failed to fetch posts",
-			"error":   "Failed to fetch posts",
+	// 登录用户可以通过 status 参数查看自己的草稿和归档文章
+	status := c.Query("status")
+	if status != "" && status != models.PostStatusDraft && status != models.PostStatusPublished && status != models.PostStatusArchived {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid status",
+			"error":   "Invalid status",
 		})
 		return
 	}
 
-	totalPages := (total + int64(pageSize) - 1) / int64(pageSize)
-	
-	// 构建分页信息，直接返回符合测试期望的格式
-	c.JSON(http.StatusOK, gin.H{
-		"posts": posts,
-		"pagination": gin.H{
-			"page":        page,
-			"page_size":   pageSize,
-			"total":       total,
-			"total_pages": totalPages,
-		},
-	})
+	respondPostList(c, services.PostListOptions{
+		ViewerID: c.GetUint("userID"),
+		Author:   c.Query("author"),
+		Status:   status,
+		Tag:      c.Query("tag"),
+		Category: c.Query("category"),
+	}, nil)
 }
 
 // GetPost 获取单个文章详情
@@ -98,8 +120,13 @@ func GetPost(c *gin.Context) {
 		return
 	}
 
+	format, ok := contentFormat(c)
+	if !ok {
+		return
+	}
+
 	// 调用服务层获取文章详情
-	post, err := postService.GetPostByID(uint(id))
+	post, err := postService.GetPostByID(uint(id), c.GetUint("userID"))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
 			"message": "Post not found",
@@ -109,6 +136,37 @@ func GetPost(c *gin.Context) {
 	}
 
 	// 直接返回文章对象
+	formatPost(post, format)
+	c.JSON(http.StatusOK, post)
+}
+
+// GetPostBySlug 根据别名获取文章详情，旧别名301重定向到当前别名
+func GetPostBySlug(c *gin.Context) {
+	slug := c.Param("slug")
+	format, ok := contentFormat(c)
+	if !ok {
+		return
+	}
+
+	post, err := postService.GetPostBySlug(slug, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "Post not found",
+			"error":   "Post not found",
+		})
+		return
+	}
+
+	if post.Slug != slug {
+		location := "/api/v1/posts/by-slug/" + url.PathEscape(post.Slug)
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, location)
+		return
+	}
+
+	formatPost(post, format)
 	c.JSON(http.StatusOK, post)
 }
 
@@ -143,7 +201,7 @@ func UpdatePost(c *gin.Context) {
 		return
 	}
 
-	post, err := postService.UpdatePost(uint(id), req.Title, req.Content, userID.(uint))
+	post, err := postService.UpdatePost(uint(id), &req, userID.(uint))
 	if err != nil {
 		if err.Error() == "post not found" {
 			c.JSON(http.StatusNotFound, gin.H{
@@ -155,6 +213,26 @@ func UpdatePost(c *gin.Context) {
 				"message": "You don't have permission to update this post",
 				"error":   "You don't have permission to update this post",
 			})
+		} else if err.Error() == "invalid slug" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Slug may only contain lowercase letters, digits and single hyphens",
+				"error":   "Invalid slug",
+			})
+		} else if err.Error() == "slug taken" {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Slug is already used by another post",
+				"error":   "Slug taken",
+			})
+		} else if err.Error() == "invalid tag" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Tag names must contain at least one letter or digit",
+				"error":   "Invalid tag",
+			})
+		} else if err.Error() == "category not found" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Category not found",
+				"error":   "Category not found",
+			})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"message": "Failed to update post",
@@ -216,4 +294,157 @@ func DeletePost(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Post deleted successfully",
 	})
-}
\ No newline at end of file
+}
+
+// PublishPost 发布文章
+func PublishPost(c *gin.Context) {
+	changePostStatus(c, models.PostStatusPublished)
+}
+
+// UnpublishPost 撤回文章为草稿
+func UnpublishPost(c *gin.Context) {
+	changePostStatus(c, models.PostStatusDraft)
+}
+
+// ArchivePost 归档文章
+func ArchivePost(c *gin.Context) {
+	changePostStatus(c, models.PostStatusArchived)
+}
+
+// changePostStatus 修改文章状态（作者或拥有管理文章权限的用户可操作）
+func changePostStatus(c *gin.Context, status string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid post ID",
+			"error":   "Invalid post ID",
+		})
+		return
+	}
+
+	post, err := postService.SetStatus(uint(id), status, c.GetUint("userID"))
+	if err != nil {
+		switch err.Error() {
+		case "post not found":
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "Post not found",
+				"error":   "Post not found",
+			})
+		case "permission denied":
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "You don't have permission to update this post",
+				"error":   "You don't have permission to update this post",
+			})
+		case "post not published":
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Only published posts can be archived",
+				"error":   "Post not published",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Failed to update post",
+				"error":   "Failed to update post",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Post status updated successfully",
+		"post":    post,
+	})
+}
+
+// SchedulePost 设置草稿的定时发布时间
+func SchedulePost(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid post ID",
+			"error":   "Invalid post ID",
+		})
+		return
+	}
+
+	var req models.ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	post, err := postService.SchedulePost(uint(id), req.ScheduledAt, c.GetUint("userID"))
+	respondSchedule(c, post, err)
+}
+
+// UnschedulePost 取消草稿的定时发布
+func UnschedulePost(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid post ID",
+			"error":   "Invalid post ID",
+		})
+		return
+	}
+
+	post, err := postService.UnschedulePost(uint(id), c.GetUint("userID"))
+	respondSchedule(c, post, err)
+}
+
+// respondSchedule 返回定时发布设置结果
+func respondSchedule(c *gin.Context, post *models.Post, err error) {
+	if err != nil {
+		switch err.Error() {
+		case "post not found":
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "Post not found",
+				"error":   "Post not found",
+			})
+		case "permission denied":
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "You don't have permission to update this post",
+				"error":   "You don't have permission to update this post",
+			})
+		case "post not draft":
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Only drafts can be scheduled",
+				"error":   "Post not draft",
+			})
+		case "invalid schedule":
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Scheduled time must be in the future",
+				"error":   "Invalid schedule",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Failed to update post",
+				"error":   "Failed to update post",
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Post schedule updated successfully",
+		"post":    post,
+	})
+}
+
+// GetScheduledPosts 获取当前用户等待定时发布的草稿
+func GetScheduledPosts(c *gin.Context) {
+	posts, err := postService.GetScheduledPosts(c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to fetch posts",
+			"error":   "Failed to fetch posts",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts": posts,
+	})
+}
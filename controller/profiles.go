@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"blog-backend/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPublicProfile 获取用户的公开资料
+func GetPublicProfile(c *gin.Context) {
+	profile, err := userService.GetPublicProfile(c.Param("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "User not found",
+			"error":   "User not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user": profile,
+	})
+}
+
+// GetUserPosts 获取指定作者的文章列表
+func GetUserPosts(c *gin.Context) {
+	user, err := userService.GetUserByUsername(c.Param("username"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "User not found",
+			"error":   "User not found",
+		})
+		return
+	}
+
+	respondPostList(c, services.PostListOptions{
+		AuthorID: user.ID,
+		ViewerID: c.GetUint("userID"),
+	}, nil)
+}
@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"blog-backend/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 创建服务实例
+var revisionService = services.NewRevisionService()
+
+// GetPostRevisions 获取文章的历史版本（作者或编辑）
+func GetPostRevisions(c *gin.Context) {
+	postID, ok := postIDParam(c)
+	if !ok {
+		return
+	}
+
+	revisions, err := revisionService.GetRevisions(postID, c.GetUint("userID"))
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revisions": revisions,
+	})
+}
+
+// DiffPostRevision 获取版本与另一个版本之间的行级差异，默认与上一个版本比较
+func DiffPostRevision(c *gin.Context) {
+	postID, ok := postIDParam(c)
+	if !ok {
+		return
+	}
+	number, ok := revisionParam(c)
+	if !ok {
+		return
+	}
+
+	against := number - 1
+	if value := c.Query("against"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid against revision",
+				"error":   "Invalid against revision",
+			})
+			return
+		}
+		against = parsed
+	}
+
+	diff, err := revisionService.DiffRevisions(postID, number, against, c.GetUint("userID"))
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// RestorePostRevision 将文章恢复为指定版本（作者或编辑）
+func RestorePostRevision(c *gin.Context) {
+	postID, ok := postIDParam(c)
+	if !ok {
+		return
+	}
+	number, ok := revisionParam(c)
+	if !ok {
+		return
+	}
+
+	post, err := revisionService.RestoreRevision(postID, number, c.GetUint("userID"))
+	if err != nil {
+		respondRevisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Post restored successfully",
+		"post":    post,
+	})
+}
+
+// postIDParam 解析路径中的文章ID，无效时返回400
+func postIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid post ID",
+			"error":   "Invalid post ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// revisionParam 解析路径中的版本号，无效时返回400
+func revisionParam(c *gin.Context) (int, bool) {
+	number, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid revision",
+			"error":   "Invalid revision",
+		})
+		return 0, false
+	}
+	return number, true
+}
+
+// respondRevisionError 将历史版本相关的错误转换为HTTP响应
+func respondRevisionError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "post not found":
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "Post not found",
+			"error":   "Post not found",
+		})
+	case "revision not found":
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "Revision not found",
+			"error":   "Revision not found",
+		})
+	case "permission denied":
+		c.JSON(http.StatusForbidden, gin.H{
+			"message": "You don't have permission to manage this post",
+			"error":   "You don't have permission to manage this post",
+		})
+	case "diff too large":
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"message": "Revisions differ too much to compare",
+			"error":   "Diff too large",
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to process revision",
+			"error":   "Failed to process revision",
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"blog-backend/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 创建服务实例
+var searchService = services.NewSearchService()
+
+// Search 全文搜索文章和评论
+func Search(c *gin.Context) {
+	// 分页参数
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 50 {
+		pageSize = 10
+	}
+
+	results, total, err := searchService.Search(services.SearchOptions{
+		Query:    c.Query("q"),
+		Type:     c.Query("type"),
+		ViewerID: c.GetUint("userID"),
+		Page:     page,
+		PageSize: pageSize,
+	})
+	if err != nil {
+		switch err.Error() {
+		case "invalid query":
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Search query must contain at least one word and at most 10 terms",
+				"error":   "Invalid query",
+			})
+		case "invalid type":
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid type",
+				"error":   "Invalid type",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Search failed",
+				"error":   "Search failed",
+			})
+		}
+		return
+	}
+
+	totalPages := (total + int64(pageSize) - 1) / int64(pageSize)
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"pagination": gin.H{
+			"page":        page,
+			"page_size":   pageSize,
+			"total":       total,
+			"total_pages": totalPages,
+		},
+	})
+}
@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"blog-backend/models"
+	"blog-backend/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 创建服务实例
+var seriesService = services.NewSeriesService()
+
+// GetSeries 根据ID获取系列及其文章
+func GetSeries(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid series ID",
+			"error":   "Invalid series ID",
+		})
+		return
+	}
+
+	series, err := seriesService.GetSeries(uint(id), c.GetUint("userID"))
+	respondSeries(c, http.StatusOK, "", series, err)
+}
+
+// GetSeriesBySlug 根据别名获取系列及其文章
+func GetSeriesBySlug(c *gin.Context) {
+	series, err := seriesService.GetSeriesBySlug(c.Param("slug"), c.GetUint("userID"))
+	respondSeries(c, http.StatusOK, "", series, err)
+}
+
+// CreateSeries 创建系列
+func CreateSeries(c *gin.Context) {
+	var req models.SeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	series, err := seriesService.CreateSeries(&req, c.GetUint("userID"))
+	respondSeries(c, http.StatusCreated, "Series created successfully", series, err)
+}
+
+// UpdateSeries 更新系列信息（创建者或编辑）
+func UpdateSeries(c *gin.Context) {
+	id, ok := seriesIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.SeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	series, err := seriesService.UpdateSeries(id, &req, c.GetUint("userID"))
+	respondSeries(c, http.StatusOK, "Series updated successfully", series, err)
+}
+
+// DeleteSeries 删除系列（创建者或编辑），系列中的文章保留
+func DeleteSeries(c *gin.Context) {
+	id, ok := seriesIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := seriesService.DeleteSeries(id, c.GetUint("userID")); err != nil {
+		respondSeries(c, http.StatusOK, "", nil, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Series deleted successfully",
+	})
+}
+
+// AddSeriesPost 将文章添加到系列中
+func AddSeriesPost(c *gin.Context) {
+	id, ok := seriesIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.SeriesPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	series, err := seriesService.AddPost(id, &req, c.GetUint("userID"))
+	respondSeries(c, http.StatusOK, "Post added to series", series, err)
+}
+
+// RemoveSeriesPost 将文章移出系列
+func RemoveSeriesPost(c *gin.Context) {
+	id, ok := seriesIDParam(c)
+	if !ok {
+		return
+	}
+	postID, err := strconv.ParseUint(c.Param("postId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid post ID",
+			"error":   "Invalid post ID",
+		})
+		return
+	}
+
+	series, err := seriesService.RemovePost(id, uint(postID), c.GetUint("userID"))
+	respondSeries(c, http.StatusOK, "Post removed from series", series, err)
+}
+
+// ReorderSeriesPosts 重新排列系列中的文章
+func ReorderSeriesPosts(c *gin.Context) {
+	id, ok := seriesIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req models.SeriesOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	series, err := seriesService.ReorderPosts(id, req.PostIDs, c.GetUint("userID"))
+	respondSeries(c, http.StatusOK, "Series reordered successfully", series, err)
+}
+
+// seriesIDParam 解析路径中的系列ID，无效时直接返回400
+func seriesIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid series ID",
+			"error":   "Invalid series ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondSeries 返回系列操作的结果或错误响应
+func respondSeries(c *gin.Context, status int, message string, series *models.Series, err error) {
+	if err != nil {
+		switch err.Error() {
+		case "series not found":
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "Series not found",
+				"error":   "Series not found",
+			})
+		case "post not found", "post not in series":
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "Post not found",
+				"error":   "Post not found",
+			})
+		case "permission denied":
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "You don't have permission to manage this series",
+				"error":   "You don't have permission to manage this series",
+			})
+		case "post already in series":
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Post already belongs to a series",
+				"error":   "Post already in series",
+			})
+		case "slug taken":
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Slug is already used by another series",
+				"error":   "Slug taken",
+			})
+		case "invalid slug":
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Slug may only contain lowercase letters, digits and single hyphens",
+				"error":   "Invalid slug",
+			})
+		case "invalid order":
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "post_ids must list every post in the series exactly once",
+				"error":   "Invalid order",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": "Failed to update series",
+				"error":   "Failed to update series",
+			})
+		}
+		return
+	}
+
+	if message == "" {
+		c.JSON(status, gin.H{
+			"series": series,
+		})
+		return
+	}
+	c.JSON(status, gin.H{
+		"message": message,
+		"series":  series,
+	})
+}
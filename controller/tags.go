@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"blog-backend/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 创建服务实例
+var tagService = services.NewTagService()
+
+// GetTags 获取标签列表及每个标签的文章数
+func GetTags(c *gin.Context) {
+	tags, err := tagService.GetTags()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": "Failed to fetch tags",
+			"error":   "Failed to fetch tags",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tags": tags,
+	})
+}
+
+// GetTagPosts 获取带有指定标签的文章列表
+func GetTagPosts(c *gin.Context) {
+	tag, err := tagService.GetTagBySlug(c.Param("slug"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "Tag not found",
+			"error":   "Tag not found",
+		})
+		return
+	}
+
+	respondPostList(c, services.PostListOptions{
+		ViewerID: c.GetUint("userID"),
+		Tag:      tag.Slug,
+	}, gin.H{"tag": tag})
+}
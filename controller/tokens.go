@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"blog-backend/models"
+	"blog-backend/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+var accessTokenService = services.NewAccessTokenService()
+
+// ListAccessTokens 获取当前用户的个人访问令牌列表
+func ListAccessTokens(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	tokens, err := accessTokenService.ListTokens(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"message": err.Error(),
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	items := make([]gin.H, 0, len(tokens))
+	for i := range tokens {
+		items = append(items, accessTokenView(&tokens[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"tokens": items,
+	})
+}
+
+// CreateAccessToken 创建个人访问令牌，明文令牌只在此时返回一次
+func CreateAccessToken(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	var req models.PersonalAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	token, plain, err := accessTokenService.CreateToken(userID.(uint), &req)
+	if err != nil {
+		if err.Error() == "invalid scope" || err.Error() == "expiry must be in the future" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	view := accessTokenView(token)
+	view["token"] = plain
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Token created successfully, copy it now as it will not be shown again",
+		"token":   view,
+	})
+}
+
+// RevokeAccessToken 吊销个人访问令牌
+func RevokeAccessToken(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "Unauthorized",
+			"error":   "Unauthorized",
+		})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid token ID",
+			"error":   "Invalid token ID",
+		})
+		return
+	}
+
+	if err := accessTokenService.RevokeToken(uint(id), userID.(uint)); err != nil {
+		if err.Error() == "token not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "Token not found",
+				"error":   "Token not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Token revoked successfully",
+	})
+}
+
+// accessTokenView 令牌的对外展示结构
+func accessTokenView(token *models.PersonalAccessToken) gin.H {
+	return gin.H{
+		"id":           token.ID,
+		"name":         token.Name,
+		"token_prefix": token.TokenPrefix,
+		"scopes":       token.ScopeList(),
+		"expires_at":   token.ExpiresAt,
+		"last_used_at": token.LastUsedAt,
+		"created_at":   token.CreatedAt,
+	}
+}
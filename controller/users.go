@@ -1,14 +1,21 @@
 package controller
 
 import (
-	"blog-backend/config"
 	"blog-backend/models"
+	"blog-backend/services"
+	"errors"
+	"math"
 	"net/http"
-	"time"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
+)
+
+// 创建服务实例
+var (
+	userService  = services.NewUserService()
+	tokenService = services.NewTokenService()
+	mfaService   = services.NewMFAService()
 )
 
 // Register 用户注册
@@ -22,58 +29,172 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	// 检查用户名是否已存在
-	var existingUser models.User
-	if err := config.DB.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{
-			"message": "Username already exists",
-			"error":   "Username already exists",
-		})
+	// 调用服务层创建用户
+	if err := userService.Register(&req); err != nil {
+		switch err.Error() {
+		case "username already exists":
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Username already exists",
+				"error":   "Username already exists",
+			})
+		case "email already exists":
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Email already exists",
+				"error":   "Email already exists",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
 		return
 	}
 
-	// 检查邮箱是否已存在
-	if err := config.DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{
-			"message": "Email already exists",
-			"error":   "Email already exists",
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "User registered successfully",
+	})
+}
+
+// Login 用户登录
+func Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
 		})
 		return
 	}
 
-	// 加密密码
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	// 调用服务层校验密码并签发令牌
+	result, err := userService.Login(&req, c.ClientIP())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": err.Error(),
-			"error":   err.Error(),
+		var rateLimitErr *services.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			respondTooManyRequests(c, rateLimitErr)
+		} else if err.Error() == "invalid username or password" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": "Invalid credentials",
+				"error":   "Invalid credentials",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	respondLoginResult(c, result)
+}
+
+// respondLoginResult 返回登录结果，开启两步验证时只返回挑战令牌
+func respondLoginResult(c *gin.Context, result *services.LoginResult) {
+	// 客户端需调用 /auth/login/mfa 完成登录
+	if result.MFAToken != "" {
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "Two-factor authentication required",
+			"mfa_required": true,
+			"mfa_token":    result.MFAToken,
+		})
+		return
+	}
+
+	respondLogin(c, result.User, result.Tokens)
+}
+
+// LoginMFA 两步验证登录，使用挑战令牌和验证码换取正式令牌
+func LoginMFA(c *gin.Context) {
+	var req models.MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
 		})
 		return
 	}
 
-	// 创建用户
-	user := models.User{
-		Username: req.Username,
-		Email:    req.Email,
-		Password: string(hashedPassword),
+	user, tokens, err := mfaService.CompleteLogin(req.MFAToken, req.Code, c.ClientIP())
+	if err != nil {
+		var rateLimitErr *services.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			respondTooManyRequests(c, rateLimitErr)
+		} else if err.Error() == "invalid mfa token" || err.Error() == "invalid code" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": "Invalid or expired two-factor code",
+				"error":   "Invalid or expired two-factor code",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
+		return
 	}
 
-	if err := config.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": err.Error(),
-			"error":   err.Error(),
+	respondLogin(c, user, tokens)
+}
+
+// respondLogin 返回登录成功的响应
+func respondLogin(c *gin.Context, user *models.User, tokens *models.TokenPair) {
+	// 直接返回符合测试期望的格式
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Login successful",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+		"user": gin.H{
+			"id":       user.ID,
+			"username": user.Username,
+			"email":    user.Email,
+			"role":     user.Role,
+		},
+	})
+}
+
+// RefreshToken 使用刷新令牌换取新的访问令牌
+func RefreshToken(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message": "User registered successfully",
+	tokens, err := tokenService.RefreshTokens(req.RefreshToken)
+	if err != nil {
+		if err.Error() == "invalid refresh token" || err.Error() == "refresh token reused" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": "Invalid or expired refresh token",
+				"error":   "Invalid or expired refresh token",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Token refreshed successfully",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
 	})
 }
 
-// Login 用户登录
-func Login(c *gin.Context) {
-	var req models.LoginRequest
+// Logout 注销登录，吊销刷新令牌所在的整个会话
+func Logout(c *gin.Context) {
+	var req models.RefreshRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"message": "Invalid request data",
@@ -82,35 +203,208 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// 查找用户
-	var user models.User
-	if err := config.DB.Where("username = ?", req.Username).First(&user).Error; err != nil {
+	if err := tokenService.RevokeSession(req.RefreshToken); err != nil {
+		if err.Error() == "invalid refresh token" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": "Invalid refresh token",
+				"error":   "Invalid refresh token",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Logged out successfully",
+	})
+}
+
+// GetProfile 获取用户个人信息
+func GetProfile(c *gin.Context) {
+	// 从中间件中获取用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "Invalid credentials",
-			"error":   "Invalid credentials",
+			"message": "Unauthorized",
+			"error":   "Unauthorized",
 		})
 		return
 	}
 
-	// 验证密码
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "Invalid credentials",
-			"error":   "Invalid credentials",
+	user, err := userService.GetUserByID(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message": "User not found",
+			"error":   "User not found",
 		})
 		return
 	}
 
-	// 创建token
-	jwtConfig := config.GetJWTConfig()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":       user.ID,
-		"username": user.Username,
-		"exp":      time.Now().Add(jwtConfig.ExpiresIn).Unix(),
+	c.JSON(http.StatusOK, gin.H{
+		"user": profileView(user),
 	})
+}
 
-	tokenString, err := token.SignedString([]byte(jwtConfig.SecretKey))
+// UpdateProfile 更新个人资料，PUT替换全部字段，PATCH只更新提供的字段
+func UpdateProfile(c *gin.Context) {
+	var req models.ProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	userID := c.GetUint("userID")
+	user, err := userService.UpdateProfile(userID, &req, c.Request.Method == http.MethodPut)
 	if err != nil {
+		switch err.Error() {
+		case "invalid url":
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Website and avatar must be http(s) URLs",
+				"error":   "Invalid URL",
+			})
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "User not found",
+				"error":   "User not found",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Profile updated successfully",
+		"user":    profileView(user),
+	})
+}
+
+// ChangePassword 修改密码，其它会话全部失效，返回当前客户端使用的新令牌
+func ChangePassword(c *gin.Context) {
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	tokens, err := userService.ChangePassword(c.GetUint("userID"), req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		var rateLimitErr *services.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			respondTooManyRequests(c, rateLimitErr)
+		} else if err.Error() == "invalid current password" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": "Current password is incorrect",
+				"error":   "Invalid current password",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Password changed successfully",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
+
+// ChangeEmail 申请修改邮箱，新邮箱验证通过后才会生效
+func ChangeEmail(c *gin.Context) {
+	var req models.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	if err := userService.RequestEmailChange(c.GetUint("userID"), req.Email, req.Password); err != nil {
+		var rateLimitErr *services.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			respondTooManyRequests(c, rateLimitErr)
+			return
+		}
+		switch err.Error() {
+		case "invalid current password":
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"message": "Current password is incorrect",
+				"error":   "Invalid current password",
+			})
+		case "email unchanged":
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "New email is the same as the current one",
+				"error":   "Email unchanged",
+			})
+		case "email already exists":
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Email already exists",
+				"error":   "Email already exists",
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Verification email sent to the new address",
+	})
+}
+
+// profileView 个人信息的返回格式
+func profileView(user *models.User) gin.H {
+	return gin.H{
+		"id":              user.ID,
+		"username":        user.Username,
+		"email":           user.Email,
+		"email_verified":  user.EmailVerified,
+		"totp_enabled":    user.TOTPEnabled,
+		"role":            user.Role,
+		"display_name":    user.DisplayName,
+		"bio":             user.Bio,
+		"website":         user.Website,
+		"avatar_url":      user.AvatarURL,
+		"deletion_due_at": user.DeletionDueAt,
+		"created_at":      user.CreatedAt,
+	}
+}
+
+// ForgotPassword 申请重置密码，向账户邮箱发送重置链接
+func ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	if err := userService.RequestPasswordReset(req.Email); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"message": err.Error(),
 			"error":   err.Error(),
@@ -118,21 +412,83 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// 直接返回符合测试期望的格式
+	// 无论邮箱是否存在都返回相同的响应
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"token":   tokenString,
-		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"email":    user.Email,
-		},
+		"message": "If the email is registered, a password reset link has been sent",
 	})
 }
 
-// GetProfile 获取用户个人信息
-func GetProfile(c *gin.Context) {
-	// 从中间件中获取用户ID
+// ResetPassword 使用重置令牌设置新密码
+func ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Invalid request data",
+			"error":   "Invalid request data",
+		})
+		return
+	}
+
+	if err := userService.ResetPassword(req.Token, req.Password); err != nil {
+		if err.Error() == "invalid or expired token" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid or expired reset token",
+				"error":   "Invalid or expired reset token",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password reset successfully",
+	})
+}
+
+// VerifyEmail 使用邮件中的令牌验证邮箱
+func VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"message": "Token is required",
+			"error":   "Token is required",
+		})
+		return
+	}
+
+	user, err := userService.VerifyEmail(token)
+	if err != nil {
+		if err.Error() == "invalid or expired token" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Invalid or expired verification token",
+				"error":   "Invalid or expired verification token",
+			})
+		} else if err.Error() == "email already exists" {
+			c.JSON(http.StatusConflict, gin.H{
+				"message": "Email already exists",
+				"error":   "Email already exists",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Email verified successfully",
+		"email":   user.Email,
+	})
+}
+
+// ResendVerification 重新发送邮箱验证邮件
+func ResendVerification(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -142,21 +498,44 @@ func GetProfile(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := config.DB.First(&user, userID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"message": "User not found",
-			"error":   "User not found",
-		})
+	if err := userService.ResendVerification(userID.(uint)); err != nil {
+		var rateLimitErr *services.RateLimitError
+		if errors.As(err, &rateLimitErr) {
+			respondTooManyRequests(c, rateLimitErr)
+		} else if err.Error() == "email already verified" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"message": "Email already verified",
+				"error":   "Email already verified",
+			})
+		} else if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "User not found",
+				"error":   "User not found",
+			})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"message": err.Error(),
+				"error":   err.Error(),
+			})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"user": gin.H{
-			"id":         user.ID,
-			"username":   user.Username,
-			"email":      user.Email,
-			"created_at": user.CreatedAt,
-		},
+		"message": "Verification email sent",
 	})
-}
\ No newline at end of file
+}
+
+// respondTooManyRequests 返回429响应并设置Retry-After头（秒）
+func respondTooManyRequests(c *gin.Context, err *services.RateLimitError) {
+	seconds := int(math.Ceil(err.RetryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"message":     "Too many requests, please try again later",
+		"error":       "Too many requests, please try again later",
+		"retry_after": seconds,
+	})
+}
@@ -1,15 +1,19 @@
 package middleware
 
 import (
-	"blog-backend/config"
+	"blog-backend/services"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthMiddleware JWT认证中间件
+var (
+	tokenService       = services.NewTokenService()
+	accessTokenService = services.NewAccessTokenService()
+)
+
+// AuthMiddleware 认证中间件，支持JWT访问令牌和个人访问令牌
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从请求头中获取Authorization
@@ -28,43 +32,18 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// 解析JWT
-		tokenString := parts[1]
-		jwtConfig := config.GetJWTConfig()
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// 验证签名方法
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(jwtConfig.SecretKey), nil
-		})
-
-		if err != nil || !token.Valid {
+		// 校验令牌并将用户信息存入上下文
+		if !authenticate(c, parts[1]) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
 		}
 
-		// 提取claims
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
-			return
-		}
-
-		// 将用户信息存入上下文
-		userID := uint(claims["id"].(float64))
-		username := claims["username"].(string)
-		
-		c.Set("userID", userID)
-		c.Set("username", username)
-		
 		c.Next()
 	}
 }
 
-// OptionalAuthMiddleware 可选的JWT认证中间件（不强制要求登录）
+// OptionalAuthMiddleware 可选的认证中间件（不强制要求登录）
 func OptionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
@@ -79,28 +58,37 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := parts[1]
-		jwtConfig := config.GetJWTConfig()
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(jwtConfig.SecretKey), nil
-		})
+		authenticate(c, parts[1])
 
-		if err != nil || !token.Valid {
-			c.Next()
-			return
-		}
+		c.Next()
+	}
+}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			userID := uint(claims["id"].(float64))
-			username := claims["username"].(string)
-			
-			c.Set("userID", userID)
-			c.Set("username", username)
+// authenticate 校验Bearer令牌，成功时将用户信息写入上下文
+func authenticate(c *gin.Context, token string) bool {
+	// 个人访问令牌
+	if services.IsPersonalAccessToken(token) {
+		user, pat, err := accessTokenService.Authenticate(token)
+		if err != nil {
+			return false
 		}
+		c.Set("userID", user.ID)
+		c.Set("username", user.Username)
+		c.Set("role", user.Role)
+		c.Set("authMethod", authMethodToken)
+		c.Set("tokenScopes", pat.ScopeList())
+		return true
+	}
 
-		c.Next()
+	// 解析JWT并校验会话是否已被吊销
+	claims, err := tokenService.ParseAccessToken(token)
+	if err != nil {
+		return false
 	}
-}
\ No newline at end of file
+	c.Set("userID", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("role", claims.Role)
+	c.Set("sessionID", claims.SessionID)
+	c.Set("authMethod", authMethodSession)
+	return true
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"blog-backend/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole 要求当前用户属于指定角色之一，需在AuthMiddleware之后使用
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, r := range roles {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient role"})
+		c.Abort()
+	}
+}
+
+// RequirePermission 要求当前用户的角色拥有指定权限，需在AuthMiddleware之后使用
+func RequirePermission(perm services.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !services.HasPermission(c.GetString("role"), perm) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
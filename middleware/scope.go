@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 认证方式
+const (
+	authMethodSession = "session" // 登录获得的JWT访问令牌
+	authMethodToken   = "token"   // 个人访问令牌
+)
+
+// HasScope 判断当前请求是否拥有指定权限范围
+// 通过登录会话认证的请求不受权限范围限制
+func HasScope(c *gin.Context, scope string) bool {
+	if c.GetString("authMethod") != authMethodToken {
+		return true
+	}
+	for _, s := range c.GetStringSlice("tokenScopes") {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireScope 要求个人访问令牌至少拥有其中一个权限范围，需在AuthMiddleware之后使用
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, scope := range scopes {
+			if HasScope(c, scope) {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "Token scope insufficient"})
+		c.Abort()
+	}
+}
+
+// RequireSession 要求通过登录会话认证，拒绝个人访问令牌（用于账户安全相关操作）
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("authMethod") != authMethodSession {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This action requires an interactive login"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Session 登录会话，对应一个刷新令牌族
+// 每次刷新都会在同一会话下轮换出新的刷新令牌，注销时整个会话被吊销
+type Session struct {
+	ID        string     `gorm:"primaryKey;size:64" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// RefreshToken 刷新令牌，只保存令牌的哈希值
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey"`
+	SessionID string     `gorm:"index;size:64;not null"`
+	UserID    uint       `gorm:"index;not null"`
+	TokenHash string     `gorm:"uniqueIndex;size:64;not null"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // 已被轮换使用的时间，再次出现即视为令牌被盗用
+	CreatedAt time.Time
+}
+
+// TokenPair 登录或刷新后返回给客户端的令牌
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // 访问令牌有效期（秒）
+}
+
+// 刷新令牌/注销请求结构体
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// PasswordResetToken 密码重置令牌，只保存令牌的哈希值，使用一次后失效
+type PasswordResetToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"index;not null"`
+	TokenHash string    `gorm:"uniqueIndex;size:64;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// AccountDeletionToken 注销确认令牌，没有可用本地密码的账户（如外部身份创建的账户）通过邮件确认注销
+type AccountDeletionToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"index;not null"`
+	TokenHash string    `gorm:"uniqueIndex;size:64;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// EmailVerificationToken 邮箱验证令牌，Email为待验证的邮箱地址
+type EmailVerificationToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	UserID    uint      `gorm:"index;not null"`
+	Email     string    `gorm:"not null"`
+	TokenHash string    `gorm:"uniqueIndex;size:64;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// RecoveryCode 两步验证恢复码，只保存哈希值，每个恢复码只能使用一次
+type RecoveryCode struct {
+	ID        uint   `gorm:"primaryKey"`
+	UserID    uint   `gorm:"index;not null"`
+	CodeHash  string `gorm:"size:64;not null"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// 忘记密码请求结构体
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// 确认注销请求结构体
+type ConfirmDeletionRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// 重置密码请求结构体
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// 两步验证登录请求结构体
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP验证码或恢复码
+}
+
+// TOTP验证码请求结构体
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// PersonalAccessToken 个人访问令牌，供脚本和CI使用，只保存令牌的哈希值
+type PersonalAccessToken struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"index;not null" json:"-"`
+	Name        string     `gorm:"size:100;not null" json:"name"`
+	TokenHash   string     `gorm:"uniqueIndex;size:64;not null" json:"-"`
+	TokenPrefix string     `gorm:"size:20;not null" json:"token_prefix"` // 令牌开头几位，便于用户辨认
+	Scopes      string     `gorm:"not null" json:"-"`                    // 空格分隔的权限范围
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ScopeList 返回令牌的权限范围列表
+func (t *PersonalAccessToken) ScopeList() []string {
+	return strings.Fields(t.Scopes)
+}
+
+// 个人访问令牌创建请求结构体
+type PersonalAccessTokenRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"` // 为空表示永不过期
+}
+
+// LoginAttempt 登录失败记录，Key为 user:<用户名> 或 ip:<地址>
+type LoginAttempt struct {
+	Key           string    `gorm:"column:attempt_key;primaryKey;size:191"`
+	Failures      int       `gorm:"not null;default:0"`
+	LastFailureAt time.Time `gorm:"not null"`
+}
+
+// 解除登录锁定请求结构体
+type UnlockRequest struct {
+	IP string `json:"ip"` // 可选，同时解除该IP的锁定
+}
+
+// UserIdentity 绑定到用户的外部登录身份，同一提供方的同一subject只能绑定一个用户
+type UserIdentity struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"index;not null" json:"-"`
+	Provider  string    `gorm:"size:50;not null;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject   string    `gorm:"size:191;not null;uniqueIndex:idx_identity_provider_subject" json:"-"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OAuthState 授权请求的临时状态，回调时校验后删除
+type OAuthState struct {
+	StateHash    string    `gorm:"primaryKey;size:64"`
+	BindingHash  string    `gorm:"size:64;not null;default:''"` // 发起授权的浏览器Cookie中绑定值的哈希
+	Provider     string    `gorm:"size:50;not null"`
+	Nonce        string    `gorm:"not null"`
+	CodeVerifier string    `gorm:"not null"` // PKCE校验码，换取令牌时提交
+	LinkUserID   *uint     // 非空表示为已登录用户绑定外部身份
+	ExpiresAt    time.Time `gorm:"not null"`
+	CreatedAt    time.Time
+}
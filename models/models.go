@@ -1,37 +1,178 @@
 package models
 
 import (
+	"fmt"
+	"time"
+
 	"gorm.io/gorm"
 )
 
+// 用户角色
+const (
+	RoleAdmin  = "admin"  // 管理员：拥有全部权限，可管理用户角色
+	RoleEditor = "editor" // 编辑：可管理任意文章和评论
+	RoleAuthor = "author" // 作者：可发布文章和评论
+	RoleReader = "reader" // 读者：只能发表评论
+)
+
 // User 用户模型
+// SQLite连接没有开启外键约束，关联记录不会由数据库级联删除，注销时由 AccountService.DeleteAccount 逐表删除
 type User struct {
 	gorm.Model
-	Username string `gorm:"unique;not null" json:"username"`
-	Password string `gorm:"not null" json:"-"` // 密码不返回给客户端
-	Email    string `gorm:"unique;not null" json:"email"`
-	Posts    []Post    `gorm:"foreignKey:UserID" json:"posts,omitempty"`
-	Comments []Comment `gorm:"foreignKey:UserID" json:"comments,omitempty"`
+	Username        string     `gorm:"unique;not null" json:"username"`
+	Password        string     `gorm:"not null" json:"-"` // 密码不返回给客户端
+	Email           string     `gorm:"unique;not null" json:"email"`
+	Role            string     `gorm:"size:20;not null;default:author" json:"role"`
+	DisplayName     string     `gorm:"size:100" json:"display_name"`
+	Bio             string     `gorm:"size:1000" json:"bio"`
+	Website         string     `gorm:"size:255" json:"website"`
+	AvatarURL       string     `gorm:"size:255" json:"avatar_url"`
+	EmailVerified   bool       `gorm:"not null;default:false" json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	TOTPSecret      string     `gorm:"column:totp_secret" json:"-"` // 未启用时保存待确认的密钥
+	TOTPEnabled     bool       `gorm:"column:totp_enabled;not null;default:false" json:"totp_enabled"`
+	TOTPLastStep    int64      `gorm:"column:totp_last_step;not null;default:0" json:"-"` // 最近一次使用的时间步，防止验证码重放
+	DeletionDueAt   *time.Time `json:"deletion_due_at,omitempty"`                         // 申请注销后到期删除的时间
+	Placeholder     bool       `gorm:"not null;default:false;index" json:"-"`               // 匿名化内容所属的占位用户，以该标记而不是用户名识别
+	Posts           []Post     `gorm:"foreignKey:UserID" json:"posts,omitempty"`
+	Comments        []Comment  `gorm:"foreignKey:UserID" json:"comments,omitempty"`
 }
 
+// 文章状态
+const (
+	PostStatusDraft     = "draft"     // 草稿：只有作者和编辑可见
+	PostStatusPublished = "published" // 已发布：所有人可见
+	PostStatusArchived  = "archived"  // 已归档：不出现在列表中，通过链接仍可访问，不能再评论
+)
+
 // Post 文章模型
+// 删除文章是软删除，评论、别名和历史版本保留；账户注销时由 AccountService.DeleteAccount 逐表删除
 type Post struct {
 	gorm.Model
-	Title   string    `gorm:"not null" json:"title"`
-	Content string    `gorm:"not null" json:"content"`
-	UserID  uint      `json:"user_id"`
-	User    User      `json:"user,omitempty"`
-	Comments []Comment `gorm:"foreignKey:PostID" json:"comments,omitempty"`
+	Title          string            `gorm:"not null" json:"title"`
+	Slug           string            `gorm:"size:191;index" json:"slug"`              // 当前别名，唯一性由PostSlug保证
+	Content        string            `gorm:"not null" json:"content,omitempty"`       // Markdown源文本
+	ContentHTML    string            `gorm:"type:text" json:"content_html,omitempty"` // 渲染并过滤后的HTML缓存
+	RenderVersion  int               `gorm:"not null;default:0" json:"-"`             // 生成ContentHTML时的渲染规则版本
+	Status         string            `gorm:"size:20;not null;default:published;index" json:"status"`
+	PublishedAt    *time.Time        `gorm:"index" json:"published_at"`
+	ScheduledAt    *time.Time        `gorm:"index" json:"scheduled_at"` // 草稿的定时发布时间
+	UserID         uint              `json:"user_id"`
+	User           User              `json:"user,omitempty"`
+	Comments       []Comment         `gorm:"foreignKey:PostID" json:"comments,omitempty"`
+	Slugs          []PostSlug        `gorm:"foreignKey:PostID" json:"-"`
+	Revisions      []PostRevision    `gorm:"foreignKey:PostID" json:"-"`
+	Tags           []Tag             `gorm:"many2many:post_tags" json:"tags"`
+	CategoryID     *uint             `gorm:"index" json:"category_id"`
+	Category       *Category         `json:"category,omitempty"`
+	SeriesID       *uint             `gorm:"index" json:"series_id"`
+	SeriesPosition int               `gorm:"not null;default:0" json:"series_position"` // 在系列中的序号，从1开始
+	SeriesInfo     *SeriesNavigation `gorm:"-" json:"series,omitempty"`                 // 文章详情中的系列信息和上一篇/下一篇
+	CommentCount   int64             `gorm:"->;-:migration" json:"comment_count"`       // 评论数，只在文章列表和详情中查询
+}
+
+// Series 多篇文章组成的系列（如分多期的教程），文章通过SeriesID和SeriesPosition排序
+type Series struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	Title       string    `gorm:"size:200;not null" json:"title"`
+	Slug        string    `gorm:"size:100;not null;uniqueIndex" json:"slug"`
+	Description string    `gorm:"size:1000" json:"description"`
+	UserID      uint      `gorm:"not null;index" json:"user_id"`
+	User        User      `json:"user,omitempty"`
+	Posts       []Post    `gorm:"foreignKey:SeriesID" json:"posts,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SeriesNavigation 文章所在系列的信息，以及当前读者可见的上一篇和下一篇
+type SeriesNavigation struct {
+	ID       uint     `json:"id"`
+	Title    string   `json:"title"`
+	Slug     string   `json:"slug"`
+	Position int      `json:"position"`
+	Total    int      `json:"total"`
+	Previous *PostRef `json:"previous"`
+	Next     *PostRef `json:"next"`
+}
+
+// PostRef 文章的简要信息，用于导航链接
+type PostRef struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+	Slug  string `json:"slug"`
+}
+
+// Category 文章分类，通过ParentID组成树，同级分类按Position和名称排序
+type Category struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	Name        string     `gorm:"size:100;not null" json:"name"`
+	Slug        string     `gorm:"size:100;not null;uniqueIndex" json:"slug"`
+	Description string     `gorm:"size:1000" json:"description"`
+	ParentID    *uint      `gorm:"index" json:"parent_id"`
+	Position    int        `gorm:"not null;default:0" json:"position"`
+	Children    []Category `gorm:"-" json:"children,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Tag 文章标签，名称保留首次使用时的写法，按别名去重
+type Tag struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	Name      string    `gorm:"size:50;not null" json:"name"`
+	Slug      string    `gorm:"size:80;not null;uniqueIndex" json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PostSlug 文章用过的所有别名（包括当前别名），旧别名用于重定向到文章的当前地址
+type PostSlug struct {
+	ID        uint   `gorm:"primarykey"`
+	PostID    uint   `gorm:"not null;index"`
+	Slug      string `gorm:"size:191;not null;uniqueIndex"`
+	CreatedAt time.Time
+}
+
+// PostRevision 文章的历史版本，创建和每次更新（包括回滚）后保存一份标题和正文的快照
+type PostRevision struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	PostID       uint      `gorm:"not null;uniqueIndex:idx_post_revision" json:"post_id"`
+	Number       int       `gorm:"not null;uniqueIndex:idx_post_revision" json:"number"` // 文章内从1开始递增的版本号
+	UserID       *uint     `gorm:"index" json:"user_id"`                                 // 修改者，硬删除账户后为空
+	User         *User     `json:"user,omitempty"`
+	Title        string    `gorm:"not null" json:"title"`
+	Content      string    `gorm:"type:text;not null" json:"content"`
+	RestoredFrom *int      `json:"restored_from,omitempty"` // 由回滚产生时记录来源版本号
+	CreatedAt    time.Time `json:"created_at"`
 }
 
 // Comment 评论模型
 type Comment struct {
 	gorm.Model
-	Content string `gorm:"not null" json:"content"`
-	UserID  uint   `json:"user_id"`
-	User    User   `json:"user,omitempty"`
-	PostID  uint   `json:"post_id"`
-	Post    Post   `json:"post,omitempty"`
+	Content       string    `gorm:"not null" json:"content,omitempty"`       // Markdown源文本
+	ContentHTML   string    `gorm:"type:text" json:"content_html,omitempty"` // 按评论规则渲染的HTML缓存
+	RenderVersion int       `gorm:"not null;default:0" json:"-"`
+	UserID        uint      `json:"user_id"`
+	User          User      `json:"user,omitempty"`
+	PostID        uint      `json:"post_id"`
+	Post          Post      `json:"post,omitempty"`
+	ParentID      *uint     `gorm:"index" json:"parent_id"`                // 回复的上级评论，根评论为空
+	Depth         int       `gorm:"not null;default:0" json:"depth"`       // 回复层级，根评论为0
+	Path          string    `gorm:"index" json:"path"`                     // 从根评论到本评论的ID路径，按字符串排序即为讨论串顺序
+	Deleted       bool      `gorm:"not null;default:false" json:"deleted"` // 有回复的评论删除后保留为"[deleted]"占位
+	ReplyCount    int64     `gorm:"->;-:migration" json:"reply_count"`     // 直接回复数，只在评论列表中查询
+	Replies       []Comment `gorm:"-" json:"replies,omitempty"`            // 树形列表中的回复
+}
+
+// CommentDeletedContent 已删除评论占位显示的内容
+const CommentDeletedContent = "[deleted]"
+
+// SetPath 根据上级评论设置层级和路径，评论ID需已生成；路径中的ID补零到固定宽度以便排序
+func (c *Comment) SetPath(parent *Comment) {
+	segment := fmt.Sprintf("%010d", c.ID)
+	if parent == nil {
+		c.ParentID, c.Depth, c.Path = nil, 0, segment
+		return
+	}
+	c.ParentID, c.Depth, c.Path = &parent.ID, parent.Depth+1, parent.Path+"/"+segment
 }
 
 // 用户注册请求结构体
@@ -47,13 +188,81 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// 个人资料更新请求结构体，PATCH时未提供的字段保持不变
+type ProfileRequest struct {
+	DisplayName *string `json:"display_name" binding:"omitempty,max=100"`
+	Bio         *string `json:"bio" binding:"omitempty,max=1000"`
+	Website     *string `json:"website" binding:"omitempty,max=255"`
+	AvatarURL   *string `json:"avatar_url" binding:"omitempty,max=255"`
+}
+
+// 修改密码请求结构体
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+// 修改邮箱请求结构体
+type ChangeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// 注销账户请求结构体
+type DeleteAccountRequest struct {
+	Password string `json:"password"` // 为空时向账户邮箱发送确认链接
+}
+
 // 文章创建/更新请求结构体
 type PostRequest struct {
-	Title   string `json:"title" binding:"required"`
-	Content string `json:"content" binding:"required"`
+	Title       string     `json:"title" binding:"required"`
+	Content     string     `json:"content" binding:"required"`
+	Slug        string     `json:"slug" binding:"omitempty,max=80"`                  // 自定义别名，未指定时根据标题生成
+	Tags        []string   `json:"tags" binding:"omitempty,max=10,dive,max=50"`      // 更新时未提供则保持不变，空数组清除所有标签
+	CategoryID  *uint      `json:"category_id"`                                      // 更新时未提供则保持不变，0表示取消分类
+	Status      string     `json:"status" binding:"omitempty,oneof=draft published"` // 仅创建时有效，默认为published
+	ScheduledAt *time.Time `json:"scheduled_at"`                                     // 仅创建时有效，指定后文章作为草稿保存并定时发布
+}
+
+// 定时发布请求结构体
+type ScheduleRequest struct {
+	ScheduledAt time.Time `json:"scheduled_at" binding:"required"`
+}
+
+// 分类创建/更新请求结构体
+type CategoryRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Slug        string `json:"slug" binding:"omitempty,max=80"` // 未指定时根据名称生成
+	Description string `json:"description" binding:"max=1000"`
+	ParentID    *uint  `json:"parent_id"` // 为空表示顶级分类
+	Position    int    `json:"position"`
+}
+
+// 系列创建/更新请求结构体
+type SeriesRequest struct {
+	Title       string `json:"title" binding:"required,max=200"`
+	Slug        string `json:"slug" binding:"omitempty,max=80"` // 未指定时根据标题生成
+	Description string `json:"description" binding:"max=1000"`
+}
+
+// 向系列添加文章请求结构体
+type SeriesPostRequest struct {
+	PostID   uint `json:"post_id" binding:"required"`
+	Position int  `json:"position" binding:"min=0"` // 插入位置（从1开始），0表示添加到末尾
+}
+
+// 系列文章排序请求结构体
+type SeriesOrderRequest struct {
+	PostIDs []uint `json:"post_ids" binding:"required"` // 系列中全部文章的ID，按新的顺序排列
 }
 
 // 评论创建请求结构体
 type CommentRequest struct {
-	Content string `json:"content" binding:"required"`
-}
\ No newline at end of file
+	Content  string `json:"content" binding:"required"`
+	ParentID *uint  `json:"parent_id"` // 回复的评论ID，为空时发表根评论
+}
+
+// 角色变更请求结构体
+type RoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
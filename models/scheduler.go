@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// JobLease 后台任务租约，多个实例共用数据库时保证同一任务同一时间只有一个实例执行
+type JobLease struct {
+	Name      string    `gorm:"primaryKey;size:100"`
+	Holder    string    `gorm:"size:191;not null"` // 持有租约的实例标识
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"errors"
+	"strings"
+	"time"
+)
+
+// PersonalAccessTokenPrefix 个人访问令牌的固定前缀，用于和JWT区分
+const PersonalAccessTokenPrefix = "blog_pat_"
+
+// lastUsedInterval 最近使用时间的更新间隔，避免每次请求都写数据库
+const lastUsedInterval = time.Minute
+
+// AccessTokenService 定义个人访问令牌相关的业务逻辑接口
+type AccessTokenService interface {
+	// CreateToken 创建令牌，明文令牌只在创建时返回一次
+	CreateToken(userID uint, req *models.PersonalAccessTokenRequest) (*models.PersonalAccessToken, string, error)
+	// ListTokens 获取用户的所有令牌
+	ListTokens(userID uint) ([]models.PersonalAccessToken, error)
+	// RevokeToken 吊销令牌
+	RevokeToken(id, userID uint) error
+	// Authenticate 校验令牌，返回令牌所属用户和令牌信息
+	Authenticate(token string) (*models.User, *models.PersonalAccessToken, error)
+}
+
+// accessTokenService 是AccessTokenService接口的实现
+type accessTokenService struct{}
+
+// NewAccessTokenService 创建一个新的AccessTokenService实例
+func NewAccessTokenService() AccessTokenService {
+	return &accessTokenService{}
+}
+
+// IsPersonalAccessToken 判断Bearer令牌是否为个人访问令牌
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// CreateToken 创建令牌实现
+func (s *accessTokenService) CreateToken(userID uint, req *models.PersonalAccessTokenRequest) (*models.PersonalAccessToken, string, error) {
+	// 校验并去重权限范围
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, scope := range req.Scopes {
+		if !IsValidScope(scope) {
+			return nil, "", errors.New("invalid scope")
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, "", errors.New("expiry must be in the future")
+	}
+
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, "", errors.New("failed to generate token")
+	}
+	plain := PersonalAccessTokenPrefix + secret
+
+	token := models.PersonalAccessToken{
+		UserID:      userID,
+		Name:        req.Name,
+		TokenHash:   utils.HashToken(plain),
+		TokenPrefix: plain[:len(PersonalAccessTokenPrefix)+4],
+		Scopes:      strings.Join(scopes, " "),
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if err := config.GetDB().Create(&token).Error; err != nil {
+		return nil, "", errors.New("failed to create token")
+	}
+
+	return &token, plain, nil
+}
+
+// ListTokens 获取令牌列表实现
+func (s *accessTokenService) ListTokens(userID uint) ([]models.PersonalAccessToken, error) {
+	var tokens []models.PersonalAccessToken
+	if err := config.GetDB().Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, errors.New("failed to fetch tokens")
+	}
+	return tokens, nil
+}
+
+// RevokeToken 吊销令牌实现，只能吊销自己的令牌
+func (s *accessTokenService) RevokeToken(id, userID uint) error {
+	result := config.GetDB().Where("id = ? AND user_id = ?", id, userID).Delete(&models.PersonalAccessToken{})
+	if result.Error != nil {
+		return errors.New("failed to revoke token")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("token not found")
+	}
+	return nil
+}
+
+// Authenticate 校验令牌实现
+func (s *accessTokenService) Authenticate(plain string) (*models.User, *models.PersonalAccessToken, error) {
+	db := config.GetDB()
+	now := time.Now()
+
+	var token models.PersonalAccessToken
+	if err := db.Where("token_hash = ?", utils.HashToken(plain)).First(&token).Error; err != nil {
+		return nil, nil, errors.New("invalid token")
+	}
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(now) {
+		return nil, nil, errors.New("invalid token")
+	}
+
+	var user models.User
+	if err := db.First(&user, token.UserID).Error; err != nil {
+		return nil, nil, errors.New("invalid token")
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > lastUsedInterval {
+		db.Model(&token).Update("last_used_at", now)
+	}
+
+	return &user, &token, nil
+}
@@ -0,0 +1,567 @@
+package services
+
+import (
+	"archive/zip"
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// AccountExport 用户个人数据导出内容
+type AccountExport struct {
+	ExportedAt   time.Time           `json:"exported_at"`
+	Profile      ExportProfile       `json:"profile"`
+	Posts        []ExportPost        `json:"posts"`
+	Comments     []ExportComment     `json:"comments"`
+	AccessTokens []ExportAccessToken `json:"access_tokens"`
+	Sessions     []ExportSession     `json:"sessions"`
+}
+
+// ExportProfile 导出的个人资料
+type ExportProfile struct {
+	ID            uint       `json:"id"`
+	Username      string     `json:"username"`
+	Email         string     `json:"email"`
+	EmailVerified bool       `json:"email_verified"`
+	Role          string     `json:"role"`
+	DisplayName   string     `json:"display_name"`
+	Bio           string     `json:"bio"`
+	Website       string     `json:"website"`
+	AvatarURL     string     `json:"avatar_url"`
+	TOTPEnabled   bool       `json:"totp_enabled"`
+	DeletionDueAt *time.Time `json:"deletion_due_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ExportPost 导出的文章
+type ExportPost struct {
+	ID        uint       `json:"id"`
+	Title     string     `json:"title"`
+	Slug      string     `json:"slug"`
+	Content   string     `json:"content"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"` // 已删除但仍然保存的文章
+}
+
+// ExportComment 导出的评论
+type ExportComment struct {
+	ID        uint       `json:"id"`
+	PostID    uint       `json:"post_id"`
+	Content   string     `json:"content"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// ExportAccessToken 导出的个人访问令牌（不含令牌本身）
+type ExportAccessToken struct {
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// ExportSession 导出的登录会话
+type ExportSession struct {
+	ID        string     `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// AccountService 定义个人数据导出和账户注销相关的业务逻辑接口
+type AccountService interface {
+	// Export 导出用户的个人数据
+	Export(userID uint) (*AccountExport, error)
+	// WriteExportZip 将导出数据写成ZIP压缩包
+	WriteExportZip(w io.Writer, export *AccountExport) error
+	// ScheduleDeletion 校验密码后申请注销，冷静期结束后删除
+	ScheduleDeletion(userID uint, password string) (time.Time, error)
+	// RequestDeletionConfirmation 向账户邮箱发送注销确认链接，供没有可用本地密码的账户使用
+	RequestDeletionConfirmation(userID uint) error
+	// ConfirmDeletion 使用邮件中的令牌确认注销，冷静期结束后删除
+	ConfirmDeletion(token string) (time.Time, error)
+	// CancelDeletion 在冷静期内撤销注销申请
+	CancelDeletion(userID uint) error
+	// PurgeDueAccounts 删除冷静期已结束的账户，返回删除数量
+	PurgeDueAccounts(now time.Time) (int, error)
+	// DeleteAccount 按配置的策略立即删除账户及其数据
+	DeleteAccount(userID uint) error
+}
+
+// accountService 是AccountService接口的实现
+type accountService struct {
+	tokens TokenService
+	guard  *LoginGuard
+}
+
+// NewAccountService 创建一个新的AccountService实例
+func NewAccountService() AccountService {
+	return &accountService{tokens: NewTokenService(), guard: NewLoginGuard()}
+}
+
+// Export 导出个人数据实现
+func (s *accountService) Export(userID uint) (*AccountExport, error) {
+	db := config.GetDB()
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	export := &AccountExport{
+		ExportedAt: time.Now(),
+		Profile: ExportProfile{
+			ID:            user.ID,
+			Username:      user.Username,
+			Email:         user.Email,
+			EmailVerified: user.EmailVerified,
+			Role:          user.Role,
+			DisplayName:   user.DisplayName,
+			Bio:           user.Bio,
+			Website:       user.Website,
+			AvatarURL:     user.AvatarURL,
+			TOTPEnabled:   user.TOTPEnabled,
+			DeletionDueAt: user.DeletionDueAt,
+			CreatedAt:     user.CreatedAt,
+			UpdatedAt:     user.UpdatedAt,
+		},
+		Posts:        []ExportPost{},
+		Comments:     []ExportComment{},
+		AccessTokens: []ExportAccessToken{},
+		Sessions:     []ExportSession{},
+	}
+
+	// 软删除的文章和评论仍然保存在数据库中，同样属于用户的数据
+	var posts []models.Post
+	if err := db.Unscoped().Where("user_id = ?", userID).Order("id").Find(&posts).Error; err != nil {
+		return nil, errors.New("failed to export data")
+	}
+	for _, post := range posts {
+		export.Posts = append(export.Posts, ExportPost{
+			ID:        post.ID,
+			Title:     post.Title,
+			Slug:      post.Slug,
+			Content:   post.Content,
+			Status:    post.Status,
+			CreatedAt: post.CreatedAt,
+			UpdatedAt: post.UpdatedAt,
+			DeletedAt: deletedAt(post.DeletedAt),
+		})
+	}
+
+	var comments []models.Comment
+	if err := db.Unscoped().Where("user_id = ?", userID).Order("id").Find(&comments).Error; err != nil {
+		return nil, errors.New("failed to export data")
+	}
+	for _, comment := range comments {
+		export.Comments = append(export.Comments, ExportComment{
+			ID:        comment.ID,
+			PostID:    comment.PostID,
+			Content:   comment.Content,
+			CreatedAt: comment.CreatedAt,
+			UpdatedAt: comment.UpdatedAt,
+			DeletedAt: deletedAt(comment.DeletedAt),
+		})
+	}
+
+	var tokens []models.PersonalAccessToken
+	db.Where("user_id = ?", userID).Order("id").Find(&tokens)
+	for _, token := range tokens {
+		export.AccessTokens = append(export.AccessTokens, ExportAccessToken{
+			Name:       token.Name,
+			Scopes:     token.ScopeList(),
+			ExpiresAt:  token.ExpiresAt,
+			LastUsedAt: token.LastUsedAt,
+			CreatedAt:  token.CreatedAt,
+		})
+	}
+
+	var sessions []models.Session
+	db.Where("user_id = ?", userID).Order("created_at").Find(&sessions)
+	for _, session := range sessions {
+		export.Sessions = append(export.Sessions, ExportSession{
+			ID:        session.ID,
+			CreatedAt: session.CreatedAt,
+			ExpiresAt: session.ExpiresAt,
+			RevokedAt: session.RevokedAt,
+		})
+	}
+
+	return export, nil
+}
+
+// WriteExportZip 写入ZIP压缩包实现，每类数据一个JSON文件
+func (s *accountService) WriteExportZip(w io.Writer, export *AccountExport) error {
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"posts.json", export.Posts},
+		{"comments.json", export.Comments},
+		{"access_tokens.json", export.AccessTokens},
+		{"sessions.json", export.Sessions},
+	}
+
+	for _, file := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// ScheduleDeletion 申请注销实现
+func (s *accountService) ScheduleDeletion(userID uint, password string) (time.Time, error) {
+	db := config.GetDB()
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return time.Time{}, errors.New("user not found")
+	}
+	// 与修改密码一样，当前密码的猜测与登录共用失败计数
+	if err := s.guard.Reserve(user.Username, ""); err != nil {
+		return time.Time{}, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return time.Time{}, errors.New("invalid current password")
+	}
+	s.guard.Succeed(user.Username, "")
+	return scheduleDeletion(db, &user)
+}
+
+// RequestDeletionConfirmation 发送注销确认邮件实现
+// 外部身份创建的账户只有随机密码，持有账户邮箱即可确认注销，不需要先重置密码
+func (s *accountService) RequestDeletionConfirmation(userID uint) error {
+	db := config.GetDB()
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return errors.New("user not found")
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return errors.New("failed to generate token")
+	}
+
+	accountConfig := config.GetAccountConfig()
+	now := time.Now()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// 新令牌生成后，之前未使用的令牌全部作废
+		if err := tx.Model(&models.AccountDeletionToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.AccountDeletionToken{
+			UserID:    user.ID,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: now.Add(accountConfig.DeletionConfirmTTL),
+		}).Error
+	})
+	if err != nil {
+		return errors.New("failed to create deletion token")
+	}
+
+	link := fmt.Sprintf("%s/confirm-deletion?token=%s", accountConfig.BaseURL, url.QueryEscape(token))
+	err = GetMailer().Send(&Message{
+		To:      user.Email,
+		Subject: "Confirm your account deletion",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to confirm the deletion of your account. It expires in %s.\n\n%s\n\n"+
+			"If you did not request this, you can ignore this email.\n", user.Username, accountConfig.DeletionConfirmTTL, link),
+	})
+	if err != nil {
+		return errors.New("failed to send email")
+	}
+	return nil
+}
+
+// ConfirmDeletion 确认注销实现，令牌只能使用一次
+func (s *accountService) ConfirmDeletion(token string) (time.Time, error) {
+	db := config.GetDB()
+	now := time.Now()
+
+	var deletionToken models.AccountDeletionToken
+	if err := db.Where("token_hash = ?", utils.HashToken(token)).First(&deletionToken).Error; err != nil {
+		return time.Time{}, errors.New("invalid or expired token")
+	}
+	if deletionToken.UsedAt != nil || !deletionToken.ExpiresAt.After(now) {
+		return time.Time{}, errors.New("invalid or expired token")
+	}
+
+	// 条件更新保证令牌只能使用一次
+	result := db.Model(&models.AccountDeletionToken{}).
+		Where("id = ? AND used_at IS NULL", deletionToken.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return time.Time{}, errors.New("failed to schedule deletion")
+	}
+	if result.RowsAffected == 0 {
+		return time.Time{}, errors.New("invalid or expired token")
+	}
+
+	var user models.User
+	if err := db.First(&user, deletionToken.UserID).Error; err != nil {
+		return time.Time{}, errors.New("invalid or expired token")
+	}
+	return scheduleDeletion(db, &user)
+}
+
+// scheduleDeletion 身份确认后设置注销到期时间并通知用户，已申请过时返回原到期时间
+func scheduleDeletion(db *gorm.DB, user *models.User) (time.Time, error) {
+	if user.DeletionDueAt != nil {
+		return *user.DeletionDueAt, nil
+	}
+
+	accountConfig := config.GetAccountConfig()
+	dueAt := time.Now().Add(accountConfig.DeletionGracePeriod)
+	// 最后一个管理员注销后将无人能管理站点，检查与更新在同一条语句中完成
+	query := db.Model(&models.User{}).Where("id = ? AND deletion_due_at IS NULL", user.ID)
+	if user.Role == models.RoleAdmin {
+		query = query.Scopes(otherAdminRemains)
+	}
+	result := query.Update("deletion_due_at", dueAt)
+	if result.Error != nil {
+		return time.Time{}, errors.New("failed to schedule deletion")
+	}
+	if result.RowsAffected == 0 {
+		if user.Role == models.RoleAdmin {
+			return time.Time{}, errors.New("cannot remove the last admin")
+		}
+		return time.Time{}, errors.New("failed to schedule deletion")
+	}
+
+	err := GetMailer().Send(&Message{
+		To:      user.Email,
+		Subject: "Your account is scheduled for deletion",
+		Body: fmt.Sprintf("Hi %s,\n\nYour account will be deleted on %s. "+
+			"Sign in and cancel the deletion before then if you change your mind.\n",
+			user.Username, dueAt.Format(time.RFC1123)),
+	})
+	if err != nil {
+		utils.Error("Failed to send deletion notice to user %d: %v", user.ID, err)
+	}
+
+	return dueAt, nil
+}
+
+// CancelDeletion 撤销注销申请实现
+func (s *accountService) CancelDeletion(userID uint) error {
+	result := config.GetDB().Model(&models.User{}).
+		Where("id = ? AND deletion_due_at IS NOT NULL", userID).
+		Update("deletion_due_at", nil)
+	if result.Error != nil {
+		return errors.New("failed to cancel deletion")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("deletion not scheduled")
+	}
+	return nil
+}
+
+// PurgeDueAccounts 删除到期账户实现，单个账户失败不影响其它账户
+func (s *accountService) PurgeDueAccounts(now time.Time) (int, error) {
+	var ids []uint
+	err := config.GetDB().Model(&models.User{}).
+		Where("deletion_due_at IS NOT NULL AND deletion_due_at <= ?", now).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, id := range ids {
+		if err := s.DeleteAccount(id); err != nil {
+			utils.Error("Failed to delete account %d: %v", id, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// removeAccountFromSearch 从搜索索引中删除账户的文章、这些文章下的评论以及账户发表的评论
+func removeAccountFromSearch(tx *gorm.DB, userID uint, postIDs *gorm.DB) error {
+	var ids []uint
+	if err := tx.Unscoped().Model(&models.Post{}).Where("user_id = ?", userID).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if err := GetSearcher().RemovePosts(tx, ids); err != nil {
+		return err
+	}
+	ids = nil
+	err := tx.Unscoped().Model(&models.Comment{}).Where("post_id IN (?) OR user_id = ?", postIDs, userID).Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+	return GetSearcher().RemoveComments(tx, ids)
+}
+
+// DeleteAccount 删除账户实现
+// anonymize策略下文章和评论转给占位用户，hard策略下连同他人在其文章下的评论一起删除
+// 所有删除都是物理删除，数据库外键未开启时也能保证不留下孤立记录
+func (s *accountService) DeleteAccount(userID uint) error {
+	accountConfig := config.GetAccountConfig()
+	var user models.User
+	err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&user, userID).Error; err != nil {
+			return errors.New("user not found")
+		}
+		if user.Placeholder {
+			return errors.New("cannot delete placeholder user")
+		}
+
+		postIDs := tx.Unscoped().Model(&models.Post{}).Select("id").Where("user_id = ?", userID)
+		if accountConfig.DeletionPolicy == config.DeletionPolicyHard {
+			if err := removeAccountFromSearch(tx, userID, postIDs); err != nil {
+				return err
+			}
+			// 他人文章下被回复过的评论保留为占位，回复不会失去上级
+			replied := tx.Unscoped().Model(&models.Comment{}).Select("parent_id").Where("parent_id IS NOT NULL")
+			if err := tx.Unscoped().Model(&models.Comment{}).Where("user_id = ? AND post_id NOT IN (?) AND id IN (?)", userID, postIDs, replied).
+				UpdateColumns(tombstoneColumns()).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("post_id IN (?) OR user_id = ?", postIDs, userID).Delete(&models.Comment{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("post_id IN (?)", postIDs).Delete(&models.PostSlug{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("post_id IN (?)", postIDs).Delete(&models.PostRevision{}).Error; err != nil {
+				return err
+			}
+			// 在他人文章上留下的历史版本保留，只去掉修改者
+			if err := tx.Model(&models.PostRevision{}).Where("user_id = ?", userID).Update("user_id", nil).Error; err != nil {
+				return err
+			}
+			if err := tx.Exec("DELETE FROM post_tags WHERE post_id IN (?)", postIDs).Error; err != nil {
+				return err
+			}
+			seriesIDs := tx.Model(&models.Series{}).Select("id").Where("user_id = ?", userID)
+			if err := tx.Unscoped().Model(&models.Post{}).Where("series_id IN (?)", seriesIDs).
+				UpdateColumns(map[string]interface{}{"series_id": nil, "series_position": 0}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("user_id = ?", userID).Delete(&models.Series{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.Post{}).Error; err != nil {
+				return err
+			}
+			if err := cleanupUnusedTags(tx); err != nil {
+				return err
+			}
+		} else {
+			placeholder, err := deletedUserPlaceholder(tx, accountConfig.DeletedUsername)
+			if err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&models.Comment{}).Where("user_id = ?", userID).Update("user_id", placeholder.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&models.Post{}).Where("user_id = ?", userID).Update("user_id", placeholder.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Series{}).Where("user_id = ?", userID).Update("user_id", placeholder.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.PostRevision{}).Where("user_id = ?", userID).Update("user_id", placeholder.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		// 认证相关的记录全部删除
+		for _, model := range []interface{}{
+			&models.RefreshToken{},
+			&models.Session{},
+			&models.PasswordResetToken{},
+			&models.EmailVerificationToken{},
+			&models.AccountDeletionToken{},
+			&models.RecoveryCode{},
+			&models.PersonalAccessToken{},
+			&models.UserIdentity{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().Delete(&user).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	GetLoginAttemptStore().Reset(UserKey(user.Username))
+	return nil
+}
+
+// deletedAt 软删除时间，未删除时为空
+func deletedAt(value gorm.DeletedAt) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Time
+}
+
+// deletedUserPlaceholder 获取匿名化内容所属的占位用户，不存在时创建
+// 占位用户以 Placeholder 标记识别，同名的普通用户不会得到匿名化的内容；用户名已被占用时加上随机后缀
+// 占位用户的密码是随机值的哈希，无法登录
+func deletedUserPlaceholder(tx *gorm.DB, username string) (*models.User, error) {
+	var user models.User
+	if err := tx.Where("placeholder = ?", true).First(&user).Error; err == nil {
+		return &user, nil
+	}
+
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	var taken int64
+	tx.Unscoped().Model(&models.User{}).Where("username = ?", username).Count(&taken)
+	if taken > 0 {
+		username += "-" + strings.ToLower(secret[:8])
+	}
+	user = models.User{
+		Username:    username,
+		Password:    string(hashed),
+		Email:       username + "@users.invalid",
+		Role:        models.RoleReader,
+		DisplayName: "Deleted user",
+		Placeholder: true,
+	}
+	if err := tx.Create(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
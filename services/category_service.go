@@ -0,0 +1,221 @@
+package services
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// CategoryService 定义文章分类相关的业务逻辑接口
+type CategoryService interface {
+	// GetCategoryTree 获取完整的分类树
+	GetCategoryTree() ([]models.Category, error)
+	// GetCategoryBySlug 根据别名获取分类及其子分类
+	GetCategoryBySlug(slug string) (*models.Category, error)
+	// CreateCategory 创建分类
+	CreateCategory(req *models.CategoryRequest) (*models.Category, error)
+	// UpdateCategory 更新分类，可以移动到其它父分类下
+	UpdateCategory(id uint, req *models.CategoryRequest) (*models.Category, error)
+	// DeleteCategory 删除分类。仍有文章时必须指定reassignTo，文章转到该分类下；子分类移动到被删除分类的父分类下
+	DeleteCategory(id, reassignTo uint) error
+}
+
+// categoryService 是CategoryService接口的实现
+type categoryService struct{}
+
+// NewCategoryService 创建一个新的CategoryService实例
+func NewCategoryService() CategoryService {
+	return &categoryService{}
+}
+
+// GetCategoryTree 获取分类树实现
+func (s *categoryService) GetCategoryTree() ([]models.Category, error) {
+	categories, err := loadCategories(config.GetDB())
+	if err != nil {
+		return nil, errors.New("failed to fetch categories")
+	}
+	return buildCategoryTree(categories, nil), nil
+}
+
+// GetCategoryBySlug 根据别名获取分类实现
+func (s *categoryService) GetCategoryBySlug(slug string) (*models.Category, error) {
+	categories, err := loadCategories(config.GetDB())
+	if err != nil {
+		return nil, errors.New("failed to fetch categories")
+	}
+	for _, category := range categories {
+		if category.Slug == slug {
+			category.Children = buildCategoryTree(categories, &category.ID)
+			return &category, nil
+		}
+	}
+	return nil, errors.New("category not found")
+}
+
+// CreateCategory 创建分类实现
+func (s *categoryService) CreateCategory(req *models.CategoryRequest) (*models.Category, error) {
+	category := models.Category{}
+	err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		return saveCategory(tx, &category, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// UpdateCategory 更新分类实现
+func (s *categoryService) UpdateCategory(id uint, req *models.CategoryRequest) (*models.Category, error) {
+	var category models.Category
+	err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&category, id).Error; err != nil {
+			return errors.New("category not found")
+		}
+		return saveCategory(tx, &category, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// DeleteCategory 删除分类实现
+func (s *categoryService) DeleteCategory(id, reassignTo uint) error {
+	return config.GetDB().Transaction(func(tx *gorm.DB) error {
+		var category models.Category
+		if err := tx.First(&category, id).Error; err != nil {
+			return errors.New("category not found")
+		}
+
+		// 已删除的文章不阻止删除分类，直接取消其分类
+		if err := tx.Unscoped().Model(&models.Post{}).Where("category_id = ? AND deleted_at IS NOT NULL", id).
+			Update("category_id", nil).Error; err != nil {
+			return err
+		}
+		var postCount int64
+		tx.Model(&models.Post{}).Where("category_id = ?", id).Count(&postCount)
+		if postCount > 0 {
+			if reassignTo == 0 {
+				return errors.New("category in use")
+			}
+			if reassignTo == id {
+				return errors.New("invalid reassignment")
+			}
+			var target models.Category
+			if err := tx.First(&target, reassignTo).Error; err != nil {
+				return errors.New("invalid reassignment")
+			}
+			if err := tx.Model(&models.Post{}).Where("category_id = ?", id).Update("category_id", reassignTo).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", id).Update("parent_id", category.ParentID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&category).Error
+	})
+}
+
+// saveCategory 校验请求并保存分类，父分类不能是自己或自己的子孙分类
+func saveCategory(tx *gorm.DB, category *models.Category, req *models.CategoryRequest) error {
+	slug := req.Slug
+	if slug == "" {
+		slug = utils.Slugify(req.Name)
+	}
+	if !utils.IsValidSlug(slug) {
+		return errors.New("invalid slug")
+	}
+	var count int64
+	tx.Model(&models.Category{}).Where("slug = ? AND id <> ?", slug, category.ID).Count(&count)
+	if count > 0 {
+		return errors.New("slug taken")
+	}
+
+	if req.ParentID != nil {
+		categories, err := loadCategories(tx)
+		if err != nil {
+			return err
+		}
+		if !categoryExists(categories, *req.ParentID) {
+			return errors.New("parent not found")
+		}
+		if category.ID != 0 {
+			for _, id := range descendantIDs(categories, category.ID) {
+				if id == *req.ParentID {
+					return errors.New("invalid parent")
+				}
+			}
+		}
+	}
+
+	category.Name = req.Name
+	category.Slug = slug
+	category.Description = req.Description
+	category.ParentID = req.ParentID
+	category.Position = req.Position
+	if err := tx.Save(category).Error; err != nil {
+		return errors.New("failed to save category")
+	}
+	return nil
+}
+
+// loadCategories 按排序加载所有分类，分类数量通常很少，树结构在内存中构建
+func loadCategories(db *gorm.DB) ([]models.Category, error) {
+	var categories []models.Category
+	err := db.Order("position ASC, name ASC").Find(&categories).Error
+	return categories, err
+}
+
+// buildCategoryTree 构建parentID下的子树，parentID为nil时构建整棵树
+func buildCategoryTree(categories []models.Category, parentID *uint) []models.Category {
+	children := []models.Category{}
+	for _, category := range categories {
+		if (parentID == nil && category.ParentID == nil) ||
+			(parentID != nil && category.ParentID != nil && *category.ParentID == *parentID) {
+			category.Children = buildCategoryTree(categories, &category.ID)
+			children = append(children, category)
+		}
+	}
+	return children
+}
+
+// descendantIDs 返回分类自身及所有子孙分类的ID
+func descendantIDs(categories []models.Category, id uint) []uint {
+	ids := []uint{id}
+	for i := 0; i < len(ids); i++ {
+		for _, category := range categories {
+			if category.ParentID != nil && *category.ParentID == ids[i] {
+				ids = append(ids, category.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// categoryExists 判断分类是否存在
+func categoryExists(categories []models.Category, id uint) bool {
+	for _, category := range categories {
+		if category.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// categoryFilterIDs 根据分类别名返回用于筛选文章的分类ID（包括子孙分类），分类不存在时返回空列表
+func categoryFilterIDs(db *gorm.DB, slug string) ([]uint, error) {
+	categories, err := loadCategories(db)
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		if category.Slug == slug {
+			return descendantIDs(categories, category.ID), nil
+		}
+	}
+	return []uint{}, nil
+}
@@ -3,13 +3,44 @@ package services
 import (
 	"blog-backend/config"
 	"blog-backend/models"
+	"blog-backend/utils"
 	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 评论列表的排序方式
+const (
+	CommentOrderNewest = "newest" // 最新的在前（默认）
+	CommentOrderOldest = "oldest" // 最早的在前
+	CommentOrderThread = "thread" // 按讨论串顺序，回复紧跟在上级评论之后；只支持平铺列表的页码分页
+)
+
+// 评论列表的展示方式
+const (
+	CommentViewFlat = "flat" // 平铺列表，每条评论带有层级和路径（默认）
+	CommentViewTree = "tree" // 按根评论分页，回复嵌套在上级评论的 replies 中
 )
 
+// commentReplyCount 查询评论列表时同时统计每条评论的直接回复数
+const commentReplyCount = "comments.*, (SELECT COUNT(*) FROM comments AS replies WHERE replies.parent_id = comments.id AND replies.deleted_at IS NULL) AS reply_count"
+
+// CommentListOptions 评论列表查询条件
+type CommentListOptions struct {
+	Page     int
+	PageSize int
+	Order    string // newest（默认）、oldest 或 thread
+	View     string // flat（默认）或 tree
+	ViewerID uint   // 当前用户，文章是草稿时只有作者和管理员可以查看评论
+}
+
 // CommentService 评论服务接口
 type CommentService interface {
-	CreateComment(content string, userID uint, postID uint) (*models.Comment, error)
-	GetComments(postID uint) ([]models.Comment, int, error)
+	CreateComment(content string, userID uint, postID uint, parentID *uint) (*models.Comment, error)
+	GetComments(postID uint, opts CommentListOptions) ([]models.Comment, int64, error)
+	GetCommentsByCursor(postID uint, opts CommentListOptions, page CursorPage) ([]models.Comment, *PageCursors, error)
+	GetCommentByID(commentID uint) (*models.Comment, error)
 	UpdateComment(commentID uint, content string, userID uint) (*models.Comment, error)
 	DeleteComment(commentID uint, userID uint) error
 }
@@ -22,24 +53,65 @@ func NewCommentService() CommentService {
 	return &commentService{}
 }
 
-// CreateComment 创建评论
-func (s *commentService) CreateComment(content string, userID uint, postID uint) (*models.Comment, error) {
+// CreateComment 创建评论，parentID 不为空时回复该评论
+func (s *commentService) CreateComment(content string, userID uint, postID uint, parentID *uint) (*models.Comment, error) {
 	db := config.GetDB()
 	
-	// 检查文章是否存在
+	// 检查文章是否存在，草稿对其他人表现为不存在
 	var post models.Post
-	if err := db.First(&post, postID).Error; err != nil {
+	if err := db.First(&post, postID).Error; err != nil || !canViewPost(db, &post, userID) {
 		return nil, errors.New("post not found")
 	}
+	if post.Status == models.PostStatusArchived {
+		return nil, errors.New("comments closed")
+	}
+	
+	// 检查是否拥有发表评论的权限
+	if !userHasPermission(db, userID, PermCreateComment) {
+		return nil, errors.New("permission denied")
+	}
+	if !emailVerificationSatisfied(db, userID) {
+		return nil, errors.New("email not verified")
+	}
+	
+	// 只能回复同一篇文章下未删除的评论，且不能超过最大层级
+	var parent *models.Comment
+	if parentID != nil {
+		parent = &models.Comment{}
+		if err := db.Where("post_id = ? AND deleted = ?", postID, false).First(parent, *parentID).Error; err != nil {
+			return nil, errors.New("parent not found")
+		}
+		if parent.Depth >= config.GetCommentConfig().MaxDepth {
+			return nil, errors.New("max depth exceeded")
+		}
+	}
 	
 	// 创建评论
 	comment := models.Comment{
-		Content: content,
-		UserID:  userID,
-		PostID:  postID,
+		Content:       content,
+		ContentHTML:   utils.RenderCommentMarkdown(content),
+		RenderVersion: utils.MarkdownRenderVersion,
+		UserID:        userID,
+		PostID:        postID,
 	}
 	
-	if err := db.Create(&comment).Error; err != nil {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		// 路径包含评论自身的ID，创建后才能确定
+		comment.SetPath(parent)
+		err := tx.Model(&comment).UpdateColumns(map[string]interface{}{
+			"parent_id": comment.ParentID,
+			"depth":     comment.Depth,
+			"path":      comment.Path,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return GetSearcher().IndexComment(tx, &comment)
+	})
+	if err != nil {
 		return nil, err
 	}
 	
@@ -49,43 +121,175 @@ func (s *commentService) CreateComment(content string, userID uint, postID uint)
 	return &comment, nil
 }
 
-// GetComments 获取文章的所有评论
-func (s *commentService) GetComments(postID uint) ([]models.Comment, int, error) {
+// GetComments 分页获取文章的评论
+func (s *commentService) GetComments(postID uint, opts CommentListOptions) ([]models.Comment, int64, error) {
+	query, err := commentListQuery(postID, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	page, pageSize := opts.Page, opts.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.New("failed to fetch comments")
+	}
+
+	direction := " DESC"
+	if opts.Order == CommentOrderOldest {
+		direction = " ASC"
+	}
+	if opts.Order == CommentOrderThread {
+		query = query.Order("comments.path ASC")
+	} else {
+		query = query.Order("comments.created_at" + direction).Order("comments.id" + direction)
+	}
+	var comments []models.Comment
+	err = query.Select(commentReplyCount).Preload("User").
+		Offset((page - 1) * pageSize).Limit(pageSize).Find(&comments).Error
+	if err != nil {
+		return nil, 0, errors.New("failed to fetch comments")
+	}
+	if opts.View == CommentViewTree {
+		if comments, err = attachReplies(postID, comments); err != nil {
+			return nil, 0, err
+		}
+	}
+	return comments, total, nil
+}
+
+// GetCommentsByCursor 使用游标分页获取文章的评论
+func (s *commentService) GetCommentsByCursor(postID uint, opts CommentListOptions, page CursorPage) ([]models.Comment, *PageCursors, error) {
+	query, err := commentListQuery(postID, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts.Order == CommentOrderThread {
+		return nil, nil, errors.New("order not supported")
+	}
+
+	scope, ascending := cursorScopeComments, false
+	if opts.Order == CommentOrderOldest {
+		scope, ascending = cursorScopeCommentsOldest, true
+	}
+	query = query.Select(commentReplyCount).Preload("User")
+	comments, cursors, err := cursorPage(query, scope, "comments", ascending, page, func(comment *models.Comment) (time.Time, uint) {
+		return comment.CreatedAt, comment.ID
+	})
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("failed to fetch comments")
+	}
+	if opts.View == CommentViewTree {
+		if comments, err = attachReplies(postID, comments); err != nil {
+			return nil, nil, err
+		}
+	}
+	return comments, cursors, nil
+}
+
+// commentListQuery 检查文章对当前用户可见并构造评论列表查询，看不到的草稿视为不存在
+func commentListQuery(postID uint, opts CommentListOptions) (*gorm.DB, error) {
+	if opts.Order != "" && opts.Order != CommentOrderNewest && opts.Order != CommentOrderOldest && opts.Order != CommentOrderThread {
+		return nil, errors.New("invalid order")
+	}
+	if opts.View != "" && opts.View != CommentViewFlat && opts.View != CommentViewTree {
+		return nil, errors.New("invalid view")
+	}
+	// 树形列表按根评论分页，讨论串顺序对根评论而言就是时间正序
+	if opts.View == CommentViewTree && opts.Order == CommentOrderThread {
+		return nil, errors.New("invalid order")
+	}
+
 	db := config.GetDB()
-	
-	// 检查文章是否存在
 	var post models.Post
-	if err := db.First(&post, postID).Error; err != nil {
-		return nil, 0, errors.New("post not found")
+	if err := db.First(&post, postID).Error; err != nil || !canViewPost(db, &post, opts.ViewerID) {
+		return nil, errors.New("post not found")
 	}
-	
-	// 获取评论列表（按创建时间倒序）
-	var comments []models.Comment
-	if err := db.Where("post_id = ?", postID).Preload("User").Order("created_at DESC").Find(&comments).Error; err != nil {
-		return nil, 0, err
+	query := db.Model(&models.Comment{}).Where("comments.post_id = ?", postID)
+	if opts.View == CommentViewTree {
+		query = query.Where("comments.parent_id IS NULL")
 	}
-	
-	return comments, len(comments), nil
+	return query, nil
+}
+
+// attachReplies 查询根评论下的所有回复，按讨论串顺序嵌套到上级评论的 Replies 中
+func attachReplies(postID uint, roots []models.Comment) ([]models.Comment, error) {
+	if len(roots) == 0 {
+		return roots, nil
+	}
+	db := config.GetDB()
+	prefixes := db.Where("1 = 0")
+	for _, root := range roots {
+		prefixes = prefixes.Or("comments.path LIKE ?", root.Path+"/%")
+	}
+	var replies []models.Comment
+	err := db.Model(&models.Comment{}).Select(commentReplyCount).Preload("User").
+		Where("comments.post_id = ?", postID).Where(prefixes).Order("comments.path ASC").Find(&replies).Error
+	if err != nil {
+		return nil, errors.New("failed to fetch comments")
+	}
+
+	children := make(map[uint][]models.Comment)
+	for _, reply := range replies {
+		children[*reply.ParentID] = append(children[*reply.ParentID], reply)
+	}
+	var nest func(comment *models.Comment)
+	nest = func(comment *models.Comment) {
+		comment.Replies = children[comment.ID]
+		for i := range comment.Replies {
+			nest(&comment.Replies[i])
+		}
+	}
+	for i := range roots {
+		nest(&roots[i])
+	}
+	return roots, nil
 }
 
-// UpdateComment 更新评论（只有评论作者可以更新）
+// GetCommentByID 根据ID获取评论
+func (s *commentService) GetCommentByID(commentID uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := config.GetDB().First(&comment, commentID).Error; err != nil {
+		return nil, errors.New("comment not found")
+	}
+	return &comment, nil
+}
+
+// UpdateComment 更新评论（评论作者或评论管理员可以更新）
 func (s *commentService) UpdateComment(commentID uint, content string, userID uint) (*models.Comment, error) {
 	db := config.GetDB()
 	
-	// 查找评论
+	// 查找评论，已删除的占位评论不能再修改
 	var comment models.Comment
-	if err := db.First(&comment, commentID).Error; err != nil {
+	if err := db.Where("deleted = ?", false).First(&comment, commentID).Error; err != nil {
 		return nil, errors.New("comment not found")
 	}
 	
-	// 检查权限：只有评论作者可以更新
-	if comment.UserID != userID {
+	// 检查权限：评论作者或拥有评论管理权限的用户可以更新
+	if !canManage(db, userID, comment.UserID, PermModerateComments) {
 		return nil, errors.New("permission denied")
 	}
 	
 	// 更新评论内容
 	comment.Content = content
-	if err := db.Save(&comment).Error; err != nil {
+	comment.ContentHTML = utils.RenderCommentMarkdown(content)
+	comment.RenderVersion = utils.MarkdownRenderVersion
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&comment).Error; err != nil {
+			return err
+		}
+		return GetSearcher().IndexComment(tx, &comment)
+	})
+	if err != nil {
 		return nil, err
 	}
 	
@@ -95,25 +299,66 @@ func (s *commentService) UpdateComment(commentID uint, content string, userID ui
 	return &comment, nil
 }
 
-// DeleteComment 删除评论（评论作者或文章作者可以删除）
+// DeleteComment 删除评论（评论作者、文章作者或评论管理员可以删除）
 func (s *commentService) DeleteComment(commentID uint, userID uint) error {
 	db := config.GetDB()
 	
 	// 查找评论
 	var comment models.Comment
-	if err := db.Preload("Post").First(&comment, commentID).Error; err != nil {
+	if err := db.Preload("Post").Where("deleted = ?", false).First(&comment, commentID).Error; err != nil {
 		return errors.New("comment not found")
 	}
 	
-	// 检查权限：只有评论作者或文章作者可以删除
-	if comment.UserID != userID && comment.Post.UserID != userID {
+	// 检查权限：评论作者、文章作者或拥有评论管理权限的用户可以删除
+	if comment.Post.UserID != userID && !canManage(db, userID, comment.UserID, PermModerateComments) {
 		return errors.New("permission denied")
 	}
 	
-	// 删除评论
-	if err := db.Delete(&comment).Error; err != nil {
+	// 删除评论，同时从搜索索引中删除；有回复的评论保留为占位，回复不受影响
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := GetSearcher().RemoveComments(tx, []uint{comment.ID}); err != nil {
+			return err
+		}
+		return removeComment(tx, &comment)
+	})
+	if err != nil {
 		return err
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// removeComment 删除评论：有回复时只清空内容和作者，保留为"[deleted]"占位；
+// 没有回复时直接删除，如果上级是已经没有其它回复的占位评论，一并删除
+func removeComment(tx *gorm.DB, comment *models.Comment) error {
+	var replies int64
+	if err := tx.Model(&models.Comment{}).Where("parent_id = ?", comment.ID).Count(&replies).Error; err != nil {
+		return err
+	}
+	if replies > 0 {
+		return tx.Model(comment).UpdateColumns(tombstoneColumns()).Error
+	}
+
+	if err := tx.Delete(comment).Error; err != nil {
+		return err
+	}
+	if comment.ParentID == nil {
+		return nil
+	}
+	var parent models.Comment
+	if err := tx.Where("deleted = ?", true).First(&parent, *comment.ParentID).Error; err != nil {
+		return nil
+	}
+	return removeComment(tx, &parent)
+}
+
+// tombstoneColumns 评论变为占位时更新的字段
+func tombstoneColumns() map[string]interface{} {
+	return map[string]interface{}{
+		"content":        models.CommentDeletedContent,
+		"content_html":   utils.RenderCommentMarkdown(models.CommentDeletedContent),
+		"render_version": utils.MarkdownRenderVersion,
+		"user_id":        0,
+		"deleted":        true,
+	}
+}
@@ -0,0 +1,14 @@
+package services
+
+import "time"
+
+// RateLimitError 操作过于频繁，需等待RetryAfter后重试
+type RateLimitError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+// Error 实现error接口
+func (e *RateLimitError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AttemptState 某个键（用户名或IP）的登录失败状态
+type AttemptState struct {
+	Failures      int
+	LastFailureAt time.Time
+}
+
+// LoginAttemptStore 登录失败记录的存储接口
+type LoginAttemptStore interface {
+	// Get 获取失败状态，不存在时返回零值
+	Get(key string) (AttemptState, error)
+	// Reserve 原子地检查并预先记录一次失败：blocked 根据记录前的状态返回可以再次尝试的时间，
+	// 该时间晚于now时不记录并返回该时间；距上次失败超过window时重新计数
+	Reserve(key string, now time.Time, window time.Duration, blocked func(AttemptState) time.Time) (time.Time, error)
+	// Release 撤销一次预先记录的失败，计数不会小于0
+	Release(key string) error
+	// Reset 清除失败记录
+	Reset(key string) error
+}
+
+// memoryAttemptStore 基于内存的存储，仅适用于单实例部署
+type memoryAttemptStore struct {
+	mu      sync.Mutex
+	entries map[string]AttemptState
+}
+
+// NewMemoryAttemptStore 创建内存存储
+func NewMemoryAttemptStore() LoginAttemptStore {
+	return &memoryAttemptStore{entries: make(map[string]AttemptState)}
+}
+
+// Get 获取失败状态
+func (s *memoryAttemptStore) Get(key string) (AttemptState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.entries[key], nil
+}
+
+// Reserve 检查并记录一次失败，检查和记录在同一个锁内完成
+func (s *memoryAttemptStore) Reserve(key string, now time.Time, window time.Duration, blocked func(AttemptState) time.Time) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 记录过多时清理已过期的条目
+	if len(s.entries) > 10000 {
+		for k, v := range s.entries {
+			if now.Sub(v.LastFailureAt) > window {
+				delete(s.entries, k)
+			}
+		}
+	}
+
+	state := s.entries[key]
+	if until := blocked(state); until.After(now) {
+		return until, nil
+	}
+	if now.Sub(state.LastFailureAt) > window {
+		state.Failures = 0
+	}
+	state.Failures++
+	state.LastFailureAt = now
+	s.entries[key] = state
+	return time.Time{}, nil
+}
+
+// Release 撤销一次失败
+func (s *memoryAttemptStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state, ok := s.entries[key]; ok && state.Failures > 0 {
+		state.Failures--
+		s.entries[key] = state
+	}
+	return nil
+}
+
+// Reset 清除失败记录
+func (s *memoryAttemptStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// dbAttemptStore 基于数据库的存储，多个实例共享失败记录
+type dbAttemptStore struct{}
+
+// NewDBAttemptStore 创建数据库存储
+func NewDBAttemptStore() LoginAttemptStore {
+	return &dbAttemptStore{}
+}
+
+// dbReserveRetries 并发修改同一条记录时的最大重试次数
+const dbReserveRetries = 10
+
+// Get 获取失败状态
+func (s *dbAttemptStore) Get(key string) (AttemptState, error) {
+	var attempt models.LoginAttempt
+	err := config.GetDB().Where("attempt_key = ?", key).First(&attempt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return AttemptState{}, nil
+	}
+	if err != nil {
+		return AttemptState{}, err
+	}
+	return AttemptState{Failures: attempt.Failures, LastFailureAt: attempt.LastFailureAt}, nil
+}
+
+// Reserve 检查并记录一次失败，以读到的计数和时间作为条件更新（比较并交换），
+// 其它请求（包括其它实例）在读取之后修改了记录时更新不生效，重新读取后再判断
+func (s *dbAttemptStore) Reserve(key string, now time.Time, window time.Duration, blocked func(AttemptState) time.Time) (time.Time, error) {
+	db := config.GetDB()
+	for i := 0; i < dbReserveRetries; i++ {
+		var attempt models.LoginAttempt
+		err := db.Where("attempt_key = ?", key).First(&attempt).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			result := db.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.LoginAttempt{Key: key, Failures: 1, LastFailureAt: now})
+			if result.Error != nil {
+				return time.Time{}, result.Error
+			}
+			if result.RowsAffected == 1 {
+				return time.Time{}, nil
+			}
+			continue
+		}
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		state := AttemptState{Failures: attempt.Failures, LastFailureAt: attempt.LastFailureAt}
+		if until := blocked(state); until.After(now) {
+			return until, nil
+		}
+		if now.Sub(state.LastFailureAt) > window {
+			state.Failures = 0
+		}
+		result := db.Model(&models.LoginAttempt{}).
+			Where("attempt_key = ? AND failures = ? AND last_failure_at = ?", key, attempt.Failures, attempt.LastFailureAt).
+			UpdateColumns(map[string]interface{}{"failures": state.Failures + 1, "last_failure_at": now})
+		if result.Error != nil {
+			return time.Time{}, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return time.Time{}, nil
+		}
+	}
+	return time.Time{}, errors.New("too much contention on login attempts")
+}
+
+// Release 撤销一次失败
+func (s *dbAttemptStore) Release(key string) error {
+	return config.GetDB().Model(&models.LoginAttempt{}).Where("attempt_key = ? AND failures > 0", key).
+		UpdateColumn("failures", gorm.Expr("failures - 1")).Error
+}
+
+// Reset 清除失败记录
+func (s *dbAttemptStore) Reset(key string) error {
+	return config.GetDB().Where("attempt_key = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+var (
+	attemptStoreMu      sync.Mutex
+	defaultAttemptStore LoginAttemptStore
+)
+
+// GetLoginAttemptStore 获取当前使用的失败记录存储，首次调用时根据配置创建
+func GetLoginAttemptStore() LoginAttemptStore {
+	attemptStoreMu.Lock()
+	defer attemptStoreMu.Unlock()
+
+	if defaultAttemptStore == nil {
+		if config.GetLoginProtectionConfig().Store == "database" {
+			defaultAttemptStore = NewDBAttemptStore()
+		} else {
+			defaultAttemptStore = NewMemoryAttemptStore()
+		}
+	}
+	return defaultAttemptStore
+}
+
+// SetLoginAttemptStore 替换失败记录存储（测试时可注入新的实例）
+func SetLoginAttemptStore(store LoginAttemptStore) {
+	attemptStoreMu.Lock()
+	defer attemptStoreMu.Unlock()
+	defaultAttemptStore = store
+}
+
+// LoginGuard 按用户名和IP限制登录失败次数
+type LoginGuard struct{}
+
+// NewLoginGuard 创建LoginGuard实例
+func NewLoginGuard() *LoginGuard {
+	return &LoginGuard{}
+}
+
+// UserKey 用户名对应的记录键
+func UserKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+// IPKey IP地址对应的记录键
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Reserve 在校验密码或验证码之前调用：检查用户名和IP是否处于等待或锁定状态，未受限时预先记录一次失败
+// 检查和记录是原子的，并发的猜测不能在任何一次失败被记录之前同时通过检查；校验失败时无需再记录
+// 先检查IP，IP受限时不会增加该用户名的计数
+func (g *LoginGuard) Reserve(username, ip string) error {
+	store := GetLoginAttemptStore()
+	window := config.GetLoginProtectionConfig().Window
+	now := time.Now()
+
+	for _, key := range g.keys(username, ip) {
+		key := key
+		until, err := store.Reserve(key, now, window, func(state AttemptState) time.Time {
+			return g.blockedUntil(key, state, now)
+		})
+		if err != nil {
+			return errors.New("failed to check login attempts")
+		}
+		if until.After(now) {
+			return &RateLimitError{Message: "too many login attempts", RetryAfter: until.Sub(now)}
+		}
+	}
+	return nil
+}
+
+// Succeed 校验成功后清除该用户名的失败记录，并撤销IP预先记录的失败
+// IP之前的失败记录不清除，避免攻击者用自己的账户登录来重置计数
+func (g *LoginGuard) Succeed(username, ip string) {
+	store := GetLoginAttemptStore()
+	store.Reset(UserKey(username))
+	if ip != "" {
+		store.Release(IPKey(ip))
+	}
+}
+
+// Unlock 解除用户名（以及可选的IP）的锁定
+func (g *LoginGuard) Unlock(username, ip string) error {
+	store := GetLoginAttemptStore()
+	if err := store.Reset(UserKey(username)); err != nil {
+		return err
+	}
+	if ip != "" {
+		return store.Reset(IPKey(ip))
+	}
+	return nil
+}
+
+// keys 返回需要检查的记录键，IP在前
+func (g *LoginGuard) keys(username, ip string) []string {
+	var keys []string
+	if ip != "" {
+		keys = append(keys, IPKey(ip))
+	}
+	return append(keys, UserKey(username))
+}
+
+// blockedUntil 根据失败次数计算可以再次尝试的时间：
+// 免等待次数内不限制，之后指数退避，达到阈值后锁定
+func (g *LoginGuard) blockedUntil(key string, state AttemptState, now time.Time) time.Time {
+	cfg := config.GetLoginProtectionConfig()
+	free, threshold := cfg.UserFreeAttempts, cfg.UserLockoutThreshold
+	if strings.HasPrefix(key, "ip:") {
+		free, threshold = cfg.IPFreeAttempts, cfg.IPLockoutThreshold
+	}
+
+	if state.Failures < free || now.Sub(state.LastFailureAt) > cfg.Window {
+		return time.Time{}
+	}
+	if state.Failures >= threshold {
+		return state.LastFailureAt.Add(cfg.LockoutDuration)
+	}
+
+	delay := cfg.BaseDelay
+	for i := free; i < state.Failures && delay < cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > cfg.MaxDelay {
+		delay = cfg.MaxDelay
+	}
+	return state.LastFailureAt.Add(delay)
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"blog-backend/config"
+	"blog-backend/utils"
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message 待发送的邮件
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(msg *Message) error
+}
+
+// SMTPMailer 通过SMTP服务器发送邮件
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Send 发送邮件
+func (m *SMTPMailer) Send(msg *Message) error {
+	addr := fmt.Sprintf("%s:%d", m.Host, m.Port)
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	return smtp.SendMail(addr, auth, m.From, []string{msg.To}, buildMessage(m.From, msg))
+}
+
+// OutboxMailer 将邮件写入本地目录而不实际发送，用于开发和测试环境
+type OutboxMailer struct {
+	Dir  string
+	From string
+}
+
+// Send 将邮件保存为 .eml 文件
+func (m *OutboxMailer) Send(msg *Message) error {
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return err
+	}
+	suffix, err := utils.GenerateRandomID(4)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), suffix)
+	return os.WriteFile(filepath.Join(m.Dir, name), buildMessage(m.From, msg), 0644)
+}
+
+// buildMessage 构造RFC 5322格式的邮件内容
+func buildMessage(from string, msg *Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + msg.Subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+var (
+	mailerMu      sync.Mutex
+	defaultMailer Mailer
+)
+
+// GetMailer 获取当前使用的邮件发送器，首次调用时根据配置创建
+func GetMailer() Mailer {
+	mailerMu.Lock()
+	defer mailerMu.Unlock()
+
+	if defaultMailer == nil {
+		mailConfig := config.GetMailConfig()
+		if mailConfig.Driver == "smtp" {
+			defaultMailer = &SMTPMailer{
+				Host:     mailConfig.Host,
+				Port:     mailConfig.Port,
+				Username: mailConfig.Username,
+				Password: mailConfig.Password,
+				From:     mailConfig.From,
+			}
+		} else {
+			defaultMailer = &OutboxMailer{Dir: mailConfig.OutboxDir, From: mailConfig.From}
+		}
+	}
+	return defaultMailer
+}
+
+// SetMailer 替换邮件发送器（测试时可注入自定义实现）
+func SetMailer(m Mailer) {
+	mailerMu.Lock()
+	defer mailerMu.Unlock()
+	defaultMailer = m
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 恢复码数量和字符集（去掉了容易混淆的字符，32个字符保证随机取值无偏差）
+const (
+	recoveryCodeCount    = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz023456789"
+)
+
+// TOTPSetup 两步验证注册信息
+type TOTPSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFAService 定义两步验证相关的业务逻辑接口
+type MFAService interface {
+	// Setup 生成新的TOTP密钥，确认前不会生效
+	Setup(userID uint) (*TOTPSetup, error)
+	// Enable 使用验证码确认并启用两步验证，返回恢复码
+	Enable(userID uint, code string) ([]string, error)
+	// Disable 使用新的验证码关闭两步验证
+	Disable(userID uint, code string) error
+	// RegenerateRecoveryCodes 使用新的验证码重新生成恢复码
+	RegenerateRecoveryCodes(userID uint, code string) ([]string, error)
+	// CompleteLogin 校验挑战令牌和验证码（或恢复码），签发正式令牌
+	CompleteLogin(mfaToken, code, clientIP string) (*models.User, *models.TokenPair, error)
+}
+
+// mfaService 是MFAService接口的实现
+type mfaService struct {
+	tokens TokenService
+	guard  *LoginGuard
+}
+
+// NewMFAService 创建一个新的MFAService实例
+func NewMFAService() MFAService {
+	return &mfaService{tokens: NewTokenService(), guard: NewLoginGuard()}
+}
+
+// Setup 生成TOTP密钥实现
+func (s *mfaService) Setup(userID uint) (*TOTPSetup, error) {
+	db := config.GetDB()
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("two-factor authentication already enabled")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, errors.New("failed to generate secret")
+	}
+	if err := db.Model(&user).Update("totp_secret", secret).Error; err != nil {
+		return nil, errors.New("failed to save secret")
+	}
+
+	return &TOTPSetup{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(config.GetAccountConfig().TOTPIssuer, user.Username, secret),
+	}, nil
+}
+
+// Enable 启用两步验证实现
+func (s *mfaService) Enable(userID uint, code string) ([]string, error) {
+	db := config.GetDB()
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("two-factor authentication already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errors.New("two-factor authentication not set up")
+	}
+	if err := s.checkTOTP(db, &user, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("totp_enabled", true).Error; err != nil {
+			return err
+		}
+		var err error
+		codes, err = s.replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, errors.New("failed to enable two-factor authentication")
+	}
+
+	return codes, nil
+}
+
+// Disable 关闭两步验证实现
+func (s *mfaService) Disable(userID uint, code string) error {
+	db := config.GetDB()
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return errors.New("user not found")
+	}
+	if !user.TOTPEnabled {
+		return errors.New("two-factor authentication not enabled")
+	}
+	if err := s.checkTOTP(db, &user, code); err != nil {
+		return err
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"totp_enabled":   false,
+			"totp_secret":    "",
+			"totp_last_step": 0,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		return errors.New("failed to disable two-factor authentication")
+	}
+
+	return nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码实现，旧的恢复码全部失效
+func (s *mfaService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	db := config.GetDB()
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+	if !user.TOTPEnabled {
+		return nil, errors.New("two-factor authentication not enabled")
+	}
+	if err := s.checkTOTP(db, &user, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = s.replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, errors.New("failed to generate recovery codes")
+	}
+
+	return codes, nil
+}
+
+// CompleteLogin 两步验证登录实现
+func (s *mfaService) CompleteLogin(mfaToken, code, clientIP string) (*models.User, *models.TokenPair, error) {
+	userID, err := s.tokens.ParseMFAToken(mfaToken)
+	if err != nil {
+		return nil, nil, errors.New("invalid mfa token")
+	}
+
+	db := config.GetDB()
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil || !user.TOTPEnabled {
+		return nil, nil, errors.New("invalid mfa token")
+	}
+
+	// 验证码猜测与密码猜测共用失败计数
+	if err := s.guard.Reserve(user.Username, clientIP); err != nil {
+		return nil, nil, err
+	}
+	if !s.verifyTOTP(db, &user, code) && !s.useRecoveryCode(db, user.ID, code) {
+		return nil, nil, errors.New("invalid code")
+	}
+	s.guard.Succeed(user.Username, clientIP)
+
+	tokens, err := s.tokens.IssueTokens(&user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &user, tokens, nil
+}
+
+// checkTOTP 校验已登录用户提交的验证码，验证码的猜测与登录共用失败计数
+func (s *mfaService) checkTOTP(db *gorm.DB, user *models.User, code string) error {
+	if err := s.guard.Reserve(user.Username, ""); err != nil {
+		return err
+	}
+	if !s.verifyTOTP(db, user, code) {
+		return errors.New("invalid code")
+	}
+	s.guard.Succeed(user.Username, "")
+	return nil
+}
+
+// verifyTOTP 校验TOTP验证码，同一时间步的验证码只能使用一次
+func (s *mfaService) verifyTOTP(db *gorm.DB, user *models.User, code string) bool {
+	if user.TOTPSecret == "" {
+		return false
+	}
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now(), 1)
+	if !ok || step <= user.TOTPLastStep {
+		return false
+	}
+
+	// 条件更新防止并发请求重复使用同一个验证码
+	result := db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", user.ID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false
+	}
+	user.TOTPLastStep = step
+	return true
+}
+
+// useRecoveryCode 使用恢复码，成功后该恢复码失效
+func (s *mfaService) useRecoveryCode(db *gorm.DB, userID uint, code string) bool {
+	hash := utils.HashToken(normalizeRecoveryCode(code))
+	result := db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return result.Error == nil && result.RowsAffected > 0
+}
+
+// replaceRecoveryCodes 删除旧的恢复码并生成新的一组
+func (s *mfaService) replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		record := models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: utils.HashToken(normalizeRecoveryCode(code)),
+		}
+		if err := tx.Create(&record).Error; err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// generateRecoveryCode 生成形如 xxxxx-xxxxx 的恢复码
+func generateRecoveryCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for i, v := range buf {
+		if i == 5 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(recoveryCodeAlphabet[int(v)%len(recoveryCodeAlphabet)])
+	}
+	return b.String(), nil
+}
+
+// normalizeRecoveryCode 忽略大小写、空格和连字符
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,476 @@
+package services
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// oauthStateTTL 授权请求的有效期
+const oauthStateTTL = 10 * time.Minute
+
+// oidcHTTPClient 访问提供方接口使用的HTTP客户端
+var oidcHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// OIDCCallbackResult 授权回调的处理结果：登录或绑定身份
+type OIDCCallbackResult struct {
+	Login    *LoginResult
+	Identity *models.UserIdentity
+	Linked   bool
+}
+
+// OIDCService 定义外部OpenID Connect登录相关的业务逻辑接口
+type OIDCService interface {
+	// AuthorizationURL 生成跳转到提供方的授权地址和浏览器绑定值，linkUserID非零时为该用户绑定身份
+	AuthorizationURL(provider string, linkUserID uint) (authURL, binding string, err error)
+	// HandleCallback 校验授权回调和发起授权的浏览器，换取并验证ID令牌后登录或绑定身份
+	HandleCallback(provider, code, state, binding string) (*OIDCCallbackResult, error)
+	// ListIdentities 获取用户绑定的外部身份
+	ListIdentities(userID uint) ([]models.UserIdentity, error)
+	// UnlinkIdentity 解除绑定
+	UnlinkIdentity(userID, identityID uint) error
+}
+
+// oidcService 是OIDCService接口的实现
+type oidcService struct {
+	tokens TokenService
+}
+
+// NewOIDCService 创建一个新的OIDCService实例
+func NewOIDCService() OIDCService {
+	return &oidcService{tokens: NewTokenService()}
+}
+
+// oidcDiscovery 提供方的元数据
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcProviderCache 缓存的提供方元数据和公钥
+type oidcProviderCache struct {
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey
+}
+
+var (
+	oidcCacheMu sync.Mutex
+	oidcCache   = make(map[string]*oidcProviderCache)
+)
+
+// AuthorizationURL 生成授权地址实现
+func (s *oidcService) AuthorizationURL(name string, linkUserID uint) (string, string, error) {
+	provider, ok := config.GetOIDCProviders()[name]
+	if !ok {
+		return "", "", errors.New("provider not found")
+	}
+	discovery, err := s.discover(provider)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err1 := utils.GenerateRandomToken(32)
+	nonce, err2 := utils.GenerateRandomToken(32)
+	verifier, err3 := utils.GenerateRandomToken(32)
+	binding, err4 := utils.GenerateRandomToken(32)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return "", "", errors.New("failed to generate state")
+	}
+
+	// 绑定值写入发起授权的浏览器的Cookie，回调时必须一致，防止他人的授权回调被注入当前浏览器
+	record := models.OAuthState{
+		StateHash:    utils.HashToken(state),
+		BindingHash:  utils.HashToken(binding),
+		Provider:     provider.Name,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oauthStateTTL),
+	}
+	if linkUserID != 0 {
+		record.LinkUserID = &linkUserID
+	}
+	db := config.GetDB()
+	db.Where("expires_at < ?", time.Now()).Delete(&models.OAuthState{})
+	if err := db.Create(&record).Error; err != nil {
+		return "", "", errors.New("failed to save state")
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.ClientID},
+		"redirect_uri":          {provider.RedirectURL},
+		"scope":                 {strings.Join(provider.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {utils.PKCEChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), binding, nil
+}
+
+// HandleCallback 处理授权回调实现
+func (s *oidcService) HandleCallback(name, code, state, binding string) (*OIDCCallbackResult, error) {
+	provider, ok := config.GetOIDCProviders()[name]
+	if !ok {
+		return nil, errors.New("provider not found")
+	}
+
+	// 授权状态只能使用一次
+	db := config.GetDB()
+	var record models.OAuthState
+	if err := db.Where("state_hash = ? AND provider = ?", utils.HashToken(state), provider.Name).First(&record).Error; err != nil {
+		return nil, errors.New("invalid state")
+	}
+	result := db.Where("state_hash = ?", record.StateHash).Delete(&models.OAuthState{})
+	if result.Error != nil || result.RowsAffected == 0 || !record.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("invalid state")
+	}
+	if binding == "" || utils.HashToken(binding) != record.BindingHash {
+		return nil, errors.New("invalid state")
+	}
+
+	claims, err := s.exchange(provider, code, record.CodeVerifier, record.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	subject, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+	emailVerified := claimBool(claims["email_verified"])
+	if subject == "" {
+		return nil, errors.New("invalid id token")
+	}
+
+	var identity models.UserIdentity
+	found := db.Where("provider = ? AND subject = ?", provider.Name, subject).First(&identity).Error == nil
+
+	// 为已登录用户绑定身份
+	if record.LinkUserID != nil {
+		if found {
+			if identity.UserID != *record.LinkUserID {
+				return nil, errors.New("identity already linked")
+			}
+			return &OIDCCallbackResult{Identity: &identity, Linked: true}, nil
+		}
+		identity = models.UserIdentity{UserID: *record.LinkUserID, Provider: provider.Name, Subject: subject, Email: email}
+		if err := db.Create(&identity).Error; err != nil {
+			return nil, errors.New("failed to link identity")
+		}
+		return &OIDCCallbackResult{Identity: &identity, Linked: true}, nil
+	}
+
+	var user models.User
+	if found {
+		if err := db.First(&user, identity.UserID).Error; err != nil {
+			return nil, errors.New("user not found")
+		}
+	} else {
+		created, err := s.createUser(provider, subject, email, emailVerified, claims)
+		if err != nil {
+			return nil, err
+		}
+		user = *created
+	}
+
+	login, err := startLogin(s.tokens, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCCallbackResult{Login: login}, nil
+}
+
+// ListIdentities 获取外部身份列表实现
+func (s *oidcService) ListIdentities(userID uint) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	if err := config.GetDB().Where("user_id = ?", userID).Order("id").Find(&identities).Error; err != nil {
+		return nil, errors.New("failed to fetch identities")
+	}
+	return identities, nil
+}
+
+// UnlinkIdentity 解除绑定实现
+func (s *oidcService) UnlinkIdentity(userID, identityID uint) error {
+	result := config.GetDB().Where("id = ? AND user_id = ?", identityID, userID).Delete(&models.UserIdentity{})
+	if result.Error != nil {
+		return errors.New("failed to unlink identity")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("identity not found")
+	}
+	return nil
+}
+
+// createUser 首次通过外部身份登录时创建本地用户
+// 邮箱已被本地账户使用时不自动合并，避免通过外部身份接管他人账户，用户需登录后主动绑定
+func (s *oidcService) createUser(provider config.OIDCProvider, subject, email string, emailVerified bool, claims jwt.MapClaims) (*models.User, error) {
+	if email == "" {
+		return nil, errors.New("email required")
+	}
+
+	db := config.GetDB()
+	var count int64
+	db.Model(&models.User{}).Where("email = ?", email).Count(&count)
+	if count > 0 {
+		return nil, errors.New("email already exists")
+	}
+
+	// 外部身份创建的账户没有可用的本地密码，需要时可通过忘记密码设置
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, errors.New("failed to create user")
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.New("failed to create user")
+	}
+
+	preferred, _ := claims["preferred_username"].(string)
+	displayName, _ := claims["name"].(string)
+	user := models.User{
+		Username:      s.uniqueUsername(db, preferred, email),
+		Password:      string(hashedPassword),
+		Email:         email,
+		Role:          config.GetRBACConfig().DefaultRole,
+		DisplayName:   displayName,
+		EmailVerified: emailVerified,
+	}
+	if emailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.UserIdentity{UserID: user.ID, Provider: provider.Name, Subject: subject, Email: email}).Error
+	})
+	if err != nil {
+		return nil, errors.New("failed to create user")
+	}
+
+	if !emailVerified {
+		if err := (&userService{tokens: s.tokens}).sendVerification(&user, email); err != nil {
+			utils.Error("Failed to send verification email to user %d: %v", user.ID, err)
+		}
+	}
+
+	return &user, nil
+}
+
+// usernameInvalidChars 用户名中不允许的字符
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// uniqueUsername 根据首选用户名或邮箱生成不重复的用户名
+func (s *oidcService) uniqueUsername(db *gorm.DB, preferred, email string) string {
+	base := preferred
+	if base == "" {
+		base = strings.SplitN(email, "@", 2)[0]
+	}
+	base = usernameInvalidChars.ReplaceAllString(base, "")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+
+	candidate := base
+	for i := 2; ; i++ {
+		var count int64
+		db.Model(&models.User{}).Unscoped().Where("username = ?", candidate).Count(&count)
+		if count == 0 && !strings.EqualFold(candidate, config.GetAccountConfig().DeletedUsername) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+}
+
+// exchange 使用授权码和PKCE校验码换取ID令牌并验证
+func (s *oidcService) exchange(provider config.OIDCProvider, code, verifier, nonce string) (jwt.MapClaims, error) {
+	discovery, err := s.discover(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {provider.RedirectURL},
+		"client_id":     {provider.ClientID},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errors.New("oidc provider error")
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if provider.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(provider.ClientID), url.QueryEscape(provider.ClientSecret))
+	}
+
+	resp, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.New("oidc provider error")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("invalid authorization code")
+	}
+
+	var tokenResponse struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil || tokenResponse.IDToken == "" {
+		return nil, errors.New("oidc provider error")
+	}
+
+	return s.verifyIDToken(provider, discovery, tokenResponse.IDToken, nonce)
+}
+
+// verifyIDToken 校验ID令牌的签名、发行方、受众、有效期和nonce
+func (s *oidcService) verifyIDToken(provider config.OIDCProvider, discovery *oidcDiscovery, idToken, nonce string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(idToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.publicKey(provider, discovery, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(provider.ClientID),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid id token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid id token")
+	}
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, errors.New("invalid id token")
+	}
+	return claims, nil
+}
+
+// discover 读取提供方的元数据，结果会被缓存
+func (s *oidcService) discover(provider config.OIDCProvider) (*oidcDiscovery, error) {
+	oidcCacheMu.Lock()
+	cached := oidcCache[provider.Issuer]
+	oidcCacheMu.Unlock()
+	if cached != nil {
+		return cached.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	// 发行方必须与配置完全一致（包括末尾的"/"），只有拼接元数据地址时去掉末尾的"/"
+	metadataURL := strings.TrimSuffix(provider.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(metadataURL, &discovery); err != nil {
+		return nil, errors.New("oidc provider error")
+	}
+	if discovery.Issuer != provider.Issuer || discovery.AuthorizationEndpoint == "" ||
+		discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.New("oidc provider error")
+	}
+
+	oidcCacheMu.Lock()
+	oidcCache[provider.Issuer] = &oidcProviderCache{discovery: &discovery}
+	oidcCacheMu.Unlock()
+	return &discovery, nil
+}
+
+// publicKey 根据kid获取提供方的公钥，未知的kid会重新拉取JWKS以支持提供方轮换密钥
+func (s *oidcService) publicKey(provider config.OIDCProvider, discovery *oidcDiscovery, kid string) (*rsa.PublicKey, error) {
+	oidcCacheMu.Lock()
+	cached := oidcCache[provider.Issuer]
+	var key *rsa.PublicKey
+	if cached != nil && cached.keys != nil {
+		key = cached.keys[kid]
+	}
+	oidcCacheMu.Unlock()
+	if key != nil {
+		return key, nil
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := getJSON(discovery.JWKSURI, &jwks); err != nil {
+		return nil, errors.New("oidc provider error")
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, err1 := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, err2 := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+
+	oidcCacheMu.Lock()
+	if cached = oidcCache[provider.Issuer]; cached != nil {
+		cached.keys = keys
+	}
+	oidcCacheMu.Unlock()
+
+	if key = keys[kid]; key == nil {
+		return nil, errors.New("unknown signing key")
+	}
+	return key, nil
+}
+
+// claimBool 解析布尔类型的声明，部分提供方以字符串"true"表示
+func claimBool(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	}
+	return false
+}
+
+// getJSON 请求地址并解析JSON响应
+func getJSON(endpoint string, v interface{}) error {
+	resp, err := oidcHTTPClient.Get(endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"blog-backend/config"
+	"blog-backend/utils"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 游标所属的列表
+const (
+	cursorScopePosts          = "posts"
+	cursorScopeComments       = "comments"
+	cursorScopeCommentsOldest = "comments:oldest" // 按时间正序的评论列表，与倒序列表的游标不能混用
+)
+
+// CursorPage 游标分页参数
+type CursorPage struct {
+	Cursor string // 上一次返回的 next_cursor 或 prev_cursor，为空时从第一页开始
+	Limit  int    // 每页条数，1-100，默认10
+}
+
+// PageCursors 相邻页的游标，没有相邻页时为空
+type PageCursors struct {
+	Next string `json:"next_cursor"`
+	Prev string `json:"prev_cursor"`
+}
+
+// CheckCursorSecret 检查游标签名密钥：必须配置，且不能复用JWT密钥
+func CheckCursorSecret() error {
+	secret := config.GetPaginationConfig().CursorSecret
+	if secret == "" {
+		return errors.New("CURSOR_SECRET must be configured")
+	}
+	if secret == config.GetJWTConfig().SecretKey {
+		return errors.New("CURSOR_SECRET must differ from JWT_SECRET")
+	}
+	return nil
+}
+
+// cursorPage 按 (created_at, id) 倒序（ascending 为 true 时正序）进行键集分页：多取一条判断是否还有下一页，
+// 向前翻页时反向排序查询后再倒转，保证返回的列表始终是请求的顺序
+// table 为排序字段所在的表名，查询包含关联表时避免字段歧义
+func cursorPage[T any](query *gorm.DB, scope, table string, ascending bool, page CursorPage, key func(*T) (time.Time, uint)) ([]T, *PageCursors, error) {
+	secret := config.GetPaginationConfig().CursorSecret
+	if secret == "" {
+		return nil, nil, errors.New("cursor secret not configured")
+	}
+	limit := page.Limit
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	var cursor utils.Cursor
+	if page.Cursor != "" {
+		var err error
+		cursor, err = utils.DecodeCursor(page.Cursor, secret)
+		if err != nil || cursor.Scope != scope {
+			return nil, nil, errors.New("invalid cursor")
+		}
+		// 倒序列表向后翻页取更早的数据，正序列表或向前翻页时相反
+		if cursor.Backward != ascending {
+			query = query.Where("("+table+".created_at > ? OR ("+table+".created_at = ? AND "+table+".id > ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+		} else {
+			query = query.Where("("+table+".created_at < ? OR ("+table+".created_at = ? AND "+table+".id < ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+		}
+	}
+	if cursor.Backward != ascending {
+		query = query.Order(table + ".created_at ASC").Order(table + ".id ASC")
+	} else {
+		query = query.Order(table + ".created_at DESC").Order(table + ".id DESC")
+	}
+
+	var items []T
+	if err := query.Limit(limit + 1).Find(&items).Error; err != nil {
+		return nil, nil, err
+	}
+	more := len(items) > limit
+	if more {
+		items = items[:limit]
+	}
+	if cursor.Backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	// 向后翻页时，从游标出发说明前面还有数据；向前翻页时同理后面还有数据
+	cursors := &PageCursors{}
+	if len(items) == 0 {
+		return items, cursors, nil
+	}
+	hasNext, hasPrev := more, page.Cursor != ""
+	if cursor.Backward {
+		hasNext, hasPrev = true, more
+	}
+	if hasNext {
+		createdAt, id := key(&items[len(items)-1])
+		cursors.Next = utils.EncodeCursor(utils.Cursor{Scope: scope, CreatedAt: createdAt, ID: id}, secret)
+	}
+	if hasPrev {
+		createdAt, id := key(&items[0])
+		cursors.Prev = utils.EncodeCursor(utils.Cursor{Scope: scope, CreatedAt: createdAt, ID: id, Backward: true}, secret)
+	}
+	return items, cursors, nil
+}
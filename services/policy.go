@@ -0,0 +1,122 @@
+package services
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// Permission 权限标识
+type Permission string
+
+const (
+	PermCreatePost       Permission = "posts:create"      // 发布文章
+	PermManagePosts      Permission = "posts:manage"      // 修改/删除任意文章
+	PermCreateComment    Permission = "comments:create"   // 发表评论
+	PermModerateComments Permission = "comments:moderate" // 修改/删除任意评论
+	PermManageUsers      Permission = "users:manage"      // 管理用户角色
+	PermManageCategories Permission = "categories:manage" // 管理文章分类
+)
+
+// 个人访问令牌的权限范围
+const (
+	ScopePostsWrite       = "posts:write"       // 发布、修改、删除文章
+	ScopeCommentsWrite    = "comments:write"    // 发表、修改、删除自己的评论
+	ScopeCommentsModerate = "comments:moderate" // 修改、删除他人的评论
+	ScopeProfileRead      = "profile:read"      // 读取个人信息
+)
+
+// validScopes 所有合法的权限范围
+var validScopes = []string{ScopePostsWrite, ScopeCommentsWrite, ScopeCommentsModerate, ScopeProfileRead}
+
+// IsValidScope 判断权限范围是否合法
+func IsValidScope(scope string) bool {
+	for _, s := range validScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// rolePermissions 各角色拥有的权限
+var rolePermissions = map[string][]Permission{
+	models.RoleAdmin: {
+		PermCreatePost, PermManagePosts, PermCreateComment, PermModerateComments, PermManageUsers, PermManageCategories,
+	},
+	models.RoleEditor: {
+		PermCreatePost, PermManagePosts, PermCreateComment, PermModerateComments, PermManageCategories,
+	},
+	models.RoleAuthor: {
+		PermCreatePost, PermCreateComment,
+	},
+	models.RoleReader: {
+		PermCreateComment,
+	},
+}
+
+// otherAdminRemains 更新条件：除被更新的用户外至少还有一个未申请注销的管理员
+// 检查与更新在同一条语句中完成，并发的降级或注销不会同时通过检查
+func otherAdminRemains(db *gorm.DB) *gorm.DB {
+	return db.Where("EXISTS (SELECT 1 FROM users AS admins WHERE admins.role = ? AND admins.id <> users.id "+
+		"AND admins.deleted_at IS NULL AND admins.deletion_due_at IS NULL)", models.RoleAdmin)
+}
+
+// PromoteBootstrapAdmins 启动时将配置的初始管理员设为管理员角色，返回被提升的账户数
+// 只提升启动前已经注册的账户，运维人员先注册账户再重启服务，避免他人抢先注册配置中的用户名
+func PromoteBootstrapAdmins() (int64, error) {
+	names := config.GetRBACConfig().BootstrapAdmins
+	if len(names) == 0 {
+		return 0, nil
+	}
+	result := config.GetDB().Model(&models.User{}).
+		Where("username IN ? AND role <> ?", names, models.RoleAdmin).
+		Update("role", models.RoleAdmin)
+	return result.RowsAffected, result.Error
+}
+
+// IsValidRole 判断角色名是否合法
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission 判断角色是否拥有指定权限
+func HasPermission(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// userHasPermission 以数据库中的角色为准判断用户是否拥有指定权限
+func userHasPermission(db *gorm.DB, userID uint, perm Permission) bool {
+	var user models.User
+	if err := db.Select("id", "role").First(&user, userID).Error; err != nil {
+		return false
+	}
+	return HasPermission(user.Role, perm)
+}
+
+// canManage 资源所有者或拥有管理权限的用户可以操作该资源
+func canManage(db *gorm.DB, userID, ownerID uint, perm Permission) bool {
+	if userID == ownerID {
+		return true
+	}
+	return userHasPermission(db, userID, perm)
+}
+
+// emailVerificationSatisfied 开启邮箱验证策略时，未验证邮箱的用户不能发布内容
+func emailVerificationSatisfied(db *gorm.DB, userID uint) bool {
+	if !config.GetAccountConfig().RequireVerifiedEmail {
+		return true
+	}
+	var user models.User
+	if err := db.Select("id", "email_verified").First(&user, userID).Error; err != nil {
+		return false
+	}
+	return user.EmailVerified
+}
@@ -3,21 +3,80 @@ package services
 import (
 	"blog-backend/config"
 	"blog-backend/models"
+	"blog-backend/utils"
 	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostListOptions 文章列表查询条件
+type PostListOptions struct {
+	Page        int
+	PageSize    int
+	AuthorID    uint       // 非零时只返回该作者的文章
+	Author      string     // 非空时只返回该用户名的作者的文章
+	ViewerID    uint       // 当前用户，非零时列表中包含其本人的草稿和归档文章
+	Status      string     // 非空时只返回该状态的文章
+	Tag         string     // 非空时只返回带有该标签（别名）的文章
+	Category    string     // 非空时只返回该分类（别名）及其子孙分类下的文章
+	CreatedFrom *time.Time // 创建时间不早于该时间
+	CreatedTo   *time.Time // 创建时间不晚于该时间
+	HasComments *bool      // 非空时只返回有评论（true）或没有评论（false）的文章
+	Sort        string     // 排序字段：created（默认）、updated、comments、popular
+	Order       string     // 排序方向：desc（默认）或 asc
+}
+
+// 文章列表的排序字段
+const (
+	PostSortCreated  = "created"  // 创建时间
+	PostSortUpdated  = "updated"  // 最后修改时间
+	PostSortComments = "comments" // 评论数
+	PostSortPopular  = "popular"  // 最近一段时间内的评论数
 )
 
+// popularWindow 计算热度时统计的评论时间范围
+const popularWindow = 7 * 24 * time.Hour
+
+// postCommentCount 文章未删除的评论数，占位的已删除评论不计入
+const postCommentCount = "(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL AND NOT comments.deleted)"
+
+// postSortExpressions 允许排序的字段及对应的SQL表达式，用户输入只用于查找，不会拼接进SQL
+var postSortExpressions = map[string]string{
+	PostSortCreated:  "posts.created_at",
+	PostSortUpdated:  "posts.updated_at",
+	PostSortComments: postCommentCount,
+	PostSortPopular:  "(SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL AND NOT comments.deleted AND comments.created_at >= ?)",
+}
+
 // PostService 定义文章相关的业务逻辑接口
 type PostService interface {
 	// CreatePost 创建文章
-	CreatePost(title, content string, userID uint) (*models.Post, error)
-	// GetPosts 获取文章列表（支持分页）
-	GetPosts(page, pageSize int) ([]models.Post, int64, error)
-	// GetPostByID 根据ID获取文章详情
-	GetPostByID(id uint) (*models.Post, error)
+	CreatePost(req *models.PostRequest, userID uint) (*models.Post, error)
+	// GetPosts 获取文章列表（支持分页和按作者筛选）
+	GetPosts(opts PostListOptions) ([]models.Post, int64, error)
+	// GetPostsByCursor 使用游标分页获取文章列表，插入新文章时翻页不会重复或遗漏
+	GetPostsByCursor(opts PostListOptions, page CursorPage) ([]models.Post, *PageCursors, error)
+	// GetPostByID 根据ID获取文章详情，草稿只对作者和编辑可见
+	GetPostByID(id, viewerID uint) (*models.Post, error)
+	// GetPostBySlug 根据别名获取文章详情，旧别名同样能找到文章，调用方比较 post.Slug 判断是否需要重定向
+	GetPostBySlug(slug string, viewerID uint) (*models.Post, error)
 	// UpdatePost 更新文章
-	UpdatePost(id uint, title, content string, userID uint) (*models.Post, error)
+	UpdatePost(id uint, req *models.PostRequest, userID uint) (*models.Post, error)
 	// DeletePost 删除文章
 	DeletePost(id, userID uint) error
+	// SetStatus 发布、撤回为草稿或归档文章
+	SetStatus(id uint, status string, userID uint) (*models.Post, error)
+	// SchedulePost 设置草稿的定时发布时间
+	SchedulePost(id uint, at time.Time, userID uint) (*models.Post, error)
+	// UnschedulePost 取消草稿的定时发布
+	UnschedulePost(id, userID uint) (*models.Post, error)
+	// GetScheduledPosts 获取用户等待定时发布的草稿
+	GetScheduledPosts(userID uint) ([]models.Post, error)
+	// PublishDuePosts 发布定时发布时间已到的草稿，返回发布的数量
+	PublishDuePosts(now time.Time) (int64, error)
 }
 
 // postService 是PostService接口的实现
@@ -29,28 +88,80 @@ func NewPostService() PostService {
 }
 
 // CreatePost 创建文章实现
-func (s *postService) CreatePost(title, content string, userID uint) (*models.Post, error) {
-	// 创建文章
+func (s *postService) CreatePost(req *models.PostRequest, userID uint) (*models.Post, error) {
+	db := config.GetDB()
+
+	// 检查是否拥有发布文章的权限
+	if !userHasPermission(db, userID, PermCreatePost) {
+		return nil, errors.New("permission denied")
+	}
+	if !emailVerificationSatisfied(db, userID) {
+		return nil, errors.New("email not verified")
+	}
+
+	// 创建文章，未指定状态时直接发布，指定定时发布时间时保存为草稿
 	post := models.Post{
-		Title:   title,
-		Content: content,
-		UserID:  userID,
+		Title:         req.Title,
+		Content:       req.Content,
+		ContentHTML:   utils.RenderMarkdown(req.Content),
+		RenderVersion: utils.MarkdownRenderVersion,
+		Status:        req.Status,
+		ScheduledAt:   req.ScheduledAt,
+		UserID:        userID,
+	}
+	if post.ScheduledAt != nil {
+		if post.Status == models.PostStatusPublished || !post.ScheduledAt.After(time.Now()) {
+			return nil, errors.New("invalid schedule")
+		}
+		post.Status = models.PostStatusDraft
+	}
+	if post.Status == "" {
+		post.Status = models.PostStatusPublished
+	}
+	if post.Status == models.PostStatusPublished {
+		now := time.Now()
+		post.PublishedAt = &now
 	}
 
-	db := config.GetDB()
-	if err := db.Create(&post).Error; err != nil {
-		return nil, errors.New("failed to create post")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if req.CategoryID != nil {
+			categoryID, err := postCategoryID(tx, *req.CategoryID)
+			if err != nil {
+				return err
+			}
+			post.CategoryID = categoryID
+		}
+		if err := tx.Create(&post).Error; err != nil {
+			return errors.New("failed to create post")
+		}
+		if err := recordRevision(tx, &post, userID, nil); err != nil {
+			return err
+		}
+		if err := GetSearcher().IndexPost(tx, &post); err != nil {
+			return err
+		}
+		if err := assignSlug(tx, &post, req.Slug); err != nil {
+			return err
+		}
+		if len(req.Tags) > 0 {
+			return replacePostTags(tx, &post, req.Tags)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// 重新查询以获取关联的用户信息
-	db.Preload("User").First(&post, post.ID)
+	db.Preload("User").Preload("Tags").Preload("Category").First(&post, post.ID)
 
 	return &post, nil
 }
 
 // GetPosts 获取文章列表实现
-func (s *postService) GetPosts(page, pageSize int) ([]models.Post, int64, error) {
+func (s *postService) GetPosts(opts PostListOptions) ([]models.Post, int64, error) {
 	// 参数验证和调整
+	page, pageSize := opts.Page, opts.PageSize
 	if page < 1 {
 		page = 1
 	}
@@ -62,34 +173,164 @@ func (s *postService) GetPosts(page, pageSize int) ([]models.Post, int64, error)
 
 	// 查询文章列表
 	var posts []models.Post
-	db := config.GetDB()
+	order, err := postListOrder(opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	query, err := postListQuery(opts)
+	if err != nil {
+		return nil, 0, err
+	}
 	total := int64(0)
 	
 	// 统计总数
-	db.Model(&models.Post{}).Count(&total)
+	query.Count(&total)
 	
 	// 查询带分页的文章，预加载用户信息
-	if err := db.Preload("User").Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&posts).Error; err != nil {
+	query = query.Select("posts.*, " + postCommentCount + " AS comment_count")
+	if err := query.Preload("User").Preload("Tags").Preload("Category").Order(order).Offset(offset).Limit(pageSize).Find(&posts).Error; err != nil {
 		return nil, 0, errors.New("failed to fetch posts")
 	}
 
 	return posts, total, nil
 }
 
+// GetPostsByCursor 使用游标分页获取文章列表实现，不统计总数；游标按创建时间定位，只支持默认的排序
+func (s *postService) GetPostsByCursor(opts PostListOptions, page CursorPage) ([]models.Post, *PageCursors, error) {
+	if _, err := postListOrder(opts); err != nil {
+		return nil, nil, err
+	}
+	if (opts.Sort != "" && opts.Sort != PostSortCreated) || (opts.Order != "" && opts.Order != "desc") {
+		return nil, nil, errors.New("sort not supported")
+	}
+	query, err := postListQuery(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	query = query.Select("posts.*, " + postCommentCount + " AS comment_count").
+		Preload("User").Preload("Tags").Preload("Category")
+	posts, cursors, err := cursorPage(query, cursorScopePosts, "posts", false, page, func(post *models.Post) (time.Time, uint) {
+		return post.CreatedAt, post.ID
+	})
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("failed to fetch posts")
+	}
+	return posts, cursors, nil
+}
+
+// postListOrder 按白名单生成排序子句，排序值相同时按创建时间和ID排序保证结果稳定
+func postListOrder(opts PostListOptions) (clause.OrderBy, error) {
+	sort, order := opts.Sort, opts.Order
+	if sort == "" {
+		sort = PostSortCreated
+	}
+	if order == "" {
+		order = "desc"
+	}
+	expression, ok := postSortExpressions[sort]
+	if !ok {
+		return clause.OrderBy{}, errors.New("invalid sort")
+	}
+	if order != "asc" && order != "desc" {
+		return clause.OrderBy{}, errors.New("invalid sort")
+	}
+
+	direction := " DESC"
+	if order == "asc" {
+		direction = " ASC"
+	}
+	var vars []interface{}
+	if sort == PostSortPopular {
+		vars = append(vars, time.Now().Add(-popularWindow))
+	}
+	sql := expression + direction
+	if sort != PostSortCreated {
+		sql += ", posts.created_at" + direction
+	}
+	sql += ", posts.id" + direction
+	return clause.OrderBy{Expression: clause.Expr{SQL: sql, Vars: vars, WithoutParentheses: true}}, nil
+}
+
+// postListQuery 按筛选条件构造文章列表查询
+func postListQuery(opts PostListOptions) (*gorm.DB, error) {
+	query := config.GetDB().Model(&models.Post{})
+	if opts.AuthorID != 0 {
+		query = query.Where("user_id = ?", opts.AuthorID)
+	}
+	if opts.Author != "" {
+		query = query.Where("user_id IN (?)", config.GetDB().Model(&models.User{}).Select("id").Where("username = ?", opts.Author))
+	}
+	if opts.CreatedFrom != nil {
+		query = query.Where("posts.created_at >= ?", *opts.CreatedFrom)
+	}
+	if opts.CreatedTo != nil {
+		query = query.Where("posts.created_at <= ?", *opts.CreatedTo)
+	}
+	if opts.HasComments != nil {
+		exists := "EXISTS (SELECT 1 FROM comments WHERE comments.post_id = posts.id AND comments.deleted_at IS NULL AND NOT comments.deleted)"
+		if !*opts.HasComments {
+			exists = "NOT " + exists
+		}
+		query = query.Where(exists)
+	}
+	if opts.Status != "" {
+		query = query.Where("status = ?", opts.Status)
+	}
+	if opts.Tag != "" {
+		tagged := config.GetDB().Table("post_tags").Select("post_tags.post_id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").Where("tags.slug = ?", opts.Tag)
+		query = query.Where("id IN (?)", tagged)
+	}
+	if opts.Category != "" {
+		categoryIDs, err := categoryFilterIDs(config.GetDB(), opts.Category)
+		if err != nil {
+			return nil, errors.New("failed to fetch posts")
+		}
+		query = query.Where("category_id IN ?", categoryIDs)
+	}
+	// 列表中只有已发布的文章，以及当前用户自己的草稿和归档文章
+	if opts.ViewerID != 0 {
+		query = query.Where("status = ? OR user_id = ?", models.PostStatusPublished, opts.ViewerID)
+	} else {
+		query = query.Where("status = ?", models.PostStatusPublished)
+	}
+	return query, nil
+}
+
 // GetPostByID 根据ID获取文章详情实现
-func (s *postService) GetPostByID(id uint) (*models.Post, error) {
+func (s *postService) GetPostByID(id, viewerID uint) (*models.Post, error) {
 	var post models.Post
 	db := config.GetDB()
-	// 预加载用户和评论信息
-	if err := db.Preload("User").Preload("Comments").Preload("Comments.User").First(&post, id).Error; err != nil {
+	// 评论通过分页的评论列表获取，详情中只返回评论数
+	if err := db.Select("posts.*, "+postCommentCount+" AS comment_count").
+		Preload("User").Preload("Tags").Preload("Category").First(&post, id).Error; err != nil {
+		return nil, errors.New("post not found")
+	}
+
+	// 草稿对其他人表现为不存在
+	if !canViewPost(db, &post, viewerID) {
 		return nil, errors.New("post not found")
 	}
+	post.SeriesInfo = seriesNavigation(db, &post, viewerID)
 
 	return &post, nil
 }
 
+// GetPostBySlug 根据别名获取文章详情实现
+func (s *postService) GetPostBySlug(slug string, viewerID uint) (*models.Post, error) {
+	db := config.GetDB()
+	var postSlug models.PostSlug
+	if err := db.Where("slug = ?", slug).First(&postSlug).Error; err != nil {
+		return nil, errors.New("post not found")
+	}
+	return s.GetPostByID(postSlug.PostID, viewerID)
+}
+
 // UpdatePost 更新文章实现
-func (s *postService) UpdatePost(id uint, title, content string, userID uint) (*models.Post, error) {
+func (s *postService) UpdatePost(id uint, req *models.PostRequest, userID uint) (*models.Post, error) {
 	db := config.GetDB()
 	var post models.Post
 	
@@ -98,21 +339,54 @@ func (s *postService) UpdatePost(id uint, title, content string, userID uint) (*
 		return nil, errors.New("post not found")
 	}
 	
-	// 检查是否是文章作者
-	if post.UserID != userID {
+	// 检查是否是文章作者或拥有管理文章的权限
+	if !canManage(db, userID, post.UserID, PermManagePosts) {
 		return nil, errors.New("permission denied")
 	}
 
-	// 更新文章内容
-	post.Title = title
-	post.Content = content
+	// 更新文章内容，状态通过发布/撤回/归档接口修改；修改标题不会改变别名，保证已分享的链接稳定
+	previous := post
+	post.Title = req.Title
+	post.Content = req.Content
+	post.ContentHTML = utils.RenderMarkdown(req.Content)
+	post.RenderVersion = utils.MarkdownRenderVersion
 
-	if err := db.Save(&post).Error; err != nil {
-		return nil, errors.New("failed to update post")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if req.CategoryID != nil {
+			categoryID, err := postCategoryID(tx, *req.CategoryID)
+			if err != nil {
+				return err
+			}
+			post.CategoryID = categoryID
+		}
+		if err := ensureBaselineRevision(tx, &previous); err != nil {
+			return err
+		}
+		if err := tx.Save(&post).Error; err != nil {
+			return errors.New("failed to update post")
+		}
+		if err := recordRevision(tx, &post, userID, nil); err != nil {
+			return err
+		}
+		if err := GetSearcher().IndexPost(tx, &post); err != nil {
+			return err
+		}
+		if req.Slug != "" && req.Slug != post.Slug {
+			if err := assignSlug(tx, &post, req.Slug); err != nil {
+				return err
+			}
+		}
+		if req.Tags != nil {
+			return replacePostTags(tx, &post, req.Tags)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// 重新查询以获取关联信息
-	db.Preload("User").First(&post, id)
+	db.Preload("User").Preload("Tags").Preload("Category").First(&post, id)
 
 	return &post, nil
 }
@@ -127,15 +401,216 @@ func (s *postService) DeletePost(id, userID uint) error {
 		return errors.New("post not found")
 	}
 	
-	// 检查是否是文章作者
-	if post.UserID != userID {
+	// 检查是否是文章作者或拥有管理文章的权限
+	if !canManage(db, userID, post.UserID, PermManagePosts) {
 		return errors.New("permission denied")
 	}
 
-	// 删除文章
-	if err := db.Delete(&post).Error; err != nil {
+	// 删除文章，同时移除文章的标签并清理不再使用的标签，移出所在系列，从搜索索引中删除文章及其评论
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := replacePostTags(tx, &post, nil); err != nil {
+			return err
+		}
+		if err := detachFromSeries(tx, &post); err != nil {
+			return err
+		}
+		var commentIDs []uint
+		if err := tx.Model(&models.Comment{}).Where("post_id = ?", post.ID).Pluck("id", &commentIDs).Error; err != nil {
+			return err
+		}
+		if err := GetSearcher().RemoveComments(tx, commentIDs); err != nil {
+			return err
+		}
+		if err := GetSearcher().RemovePosts(tx, []uint{post.ID}); err != nil {
+			return err
+		}
+		return tx.Delete(&post).Error
+	})
+	if err != nil {
 		return errors.New("failed to delete post")
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// SetStatus 修改文章状态实现
+func (s *postService) SetStatus(id uint, status string, userID uint) (*models.Post, error) {
+	db := config.GetDB()
+	var post models.Post
+	if err := db.First(&post, id).Error; err != nil {
+		return nil, errors.New("post not found")
+	}
+	if !canViewPost(db, &post, userID) {
+		return nil, errors.New("post not found")
+	}
+	if !canManage(db, userID, post.UserID, PermManagePosts) {
+		return nil, errors.New("permission denied")
+	}
+
+	// 手动修改状态后不再定时发布
+	updates := map[string]interface{}{"status": status, "scheduled_at": nil}
+	switch status {
+	case models.PostStatusPublished:
+		// 重新发布归档文章时保留首次发布时间
+		if post.PublishedAt == nil {
+			updates["published_at"] = time.Now()
+		}
+	case models.PostStatusDraft:
+		updates["published_at"] = nil
+	case models.PostStatusArchived:
+		if post.Status == models.PostStatusDraft {
+			return nil, errors.New("post not published")
+		}
+	default:
+		return nil, errors.New("invalid status")
+	}
+
+	if err := db.Model(&post).Updates(updates).Error; err != nil {
+		return nil, errors.New("failed to update post")
+	}
+
+	db.Preload("User").Preload("Tags").Preload("Category").First(&post, id)
+	return &post, nil
+}
+
+// SchedulePost 设置定时发布实现
+func (s *postService) SchedulePost(id uint, at time.Time, userID uint) (*models.Post, error) {
+	if !at.After(time.Now()) {
+		return nil, errors.New("invalid schedule")
+	}
+	return s.updateSchedule(id, &at, userID)
+}
+
+// UnschedulePost 取消定时发布实现
+func (s *postService) UnschedulePost(id, userID uint) (*models.Post, error) {
+	return s.updateSchedule(id, nil, userID)
+}
+
+// updateSchedule 修改草稿的定时发布时间，at为nil时取消定时发布
+func (s *postService) updateSchedule(id uint, at *time.Time, userID uint) (*models.Post, error) {
+	db := config.GetDB()
+	var post models.Post
+	if err := db.First(&post, id).Error; err != nil {
+		return nil, errors.New("post not found")
+	}
+	if !canViewPost(db, &post, userID) {
+		return nil, errors.New("post not found")
+	}
+	if !canManage(db, userID, post.UserID, PermManagePosts) {
+		return nil, errors.New("permission denied")
+	}
+	if post.Status != models.PostStatusDraft {
+		return nil, errors.New("post not draft")
+	}
+
+	if err := db.Model(&post).Update("scheduled_at", at).Error; err != nil {
+		return nil, errors.New("failed to update post")
+	}
+
+	db.Preload("User").Preload("Tags").Preload("Category").First(&post, id)
+	return &post, nil
+}
+
+// GetScheduledPosts 获取定时发布草稿实现，按发布时间先后排序
+func (s *postService) GetScheduledPosts(userID uint) ([]models.Post, error) {
+	var posts []models.Post
+	err := config.GetDB().Preload("User").Preload("Tags").Preload("Category").
+		Where("user_id = ? AND status = ? AND scheduled_at IS NOT NULL", userID, models.PostStatusDraft).
+		Order("scheduled_at ASC").Find(&posts).Error
+	if err != nil {
+		return nil, errors.New("failed to fetch posts")
+	}
+	return posts, nil
+}
+
+// PublishDuePosts 发布到期草稿实现。更新语句带有状态条件，
+// 即使多个实例同时执行，同一篇文章也只会被发布一次
+func (s *postService) PublishDuePosts(now time.Time) (int64, error) {
+	var published int64
+	err := config.GetDB().Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Post{}).
+			Where("status = ? AND scheduled_at IS NOT NULL AND scheduled_at <= ?", models.PostStatusDraft, now).
+			Updates(map[string]interface{}{
+				"status":       models.PostStatusPublished,
+				"published_at": gorm.Expr("scheduled_at"),
+				"scheduled_at": nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		published = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, errors.New("failed to publish scheduled posts")
+	}
+	return published, nil
+}
+
+// assignSlug 设置文章的别名并记录到别名历史。custom为空时根据标题生成，
+// 与其它文章冲突时追加数字后缀；自定义别名必须是规范形式且未被其它文章使用过
+func assignSlug(tx *gorm.DB, post *models.Post, custom string) error {
+	slug := custom
+	if custom == "" {
+		base := utils.Slugify(post.Title)
+		if base == "" {
+			base = fmt.Sprintf("post-%d", post.ID)
+		}
+		slug = availableSlug(tx, base, post.ID)
+	} else {
+		if !utils.IsValidSlug(custom) {
+			return errors.New("invalid slug")
+		}
+		if !slugAvailable(tx, custom, post.ID) {
+			return errors.New("slug taken")
+		}
+	}
+
+	// 重新使用自己的旧别名时历史记录已经存在
+	var existing models.PostSlug
+	if err := tx.Where("slug = ? AND post_id = ?", slug, post.ID).First(&existing).Error; err != nil {
+		if err := tx.Create(&models.PostSlug{PostID: post.ID, Slug: slug}).Error; err != nil {
+			return errors.New("slug taken")
+		}
+	}
+	if err := tx.Model(post).UpdateColumn("slug", slug).Error; err != nil {
+		return errors.New("failed to update post")
+	}
+	return nil
+}
+
+// availableSlug 返回base或追加数字后缀后第一个可用的别名
+func availableSlug(tx *gorm.DB, base string, postID uint) string {
+	slug := base
+	for i := 2; !slugAvailable(tx, slug, postID); i++ {
+		slug = fmt.Sprintf("%s-%d", base, i)
+	}
+	return slug
+}
+
+// slugAvailable 判断别名是否未被其它文章使用过（包括其它文章的旧别名）
+func slugAvailable(tx *gorm.DB, slug string, postID uint) bool {
+	var count int64
+	tx.Model(&models.PostSlug{}).Where("slug = ? AND post_id <> ?", slug, postID).Count(&count)
+	return count == 0
+}
+
+// postCategoryID 校验文章的分类，0表示不属于任何分类
+func postCategoryID(tx *gorm.DB, id uint) (*uint, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	var category models.Category
+	if err := tx.First(&category, id).Error; err != nil {
+		return nil, errors.New("category not found")
+	}
+	return &category.ID, nil
+}
+
+// canViewPost 判断用户能否查看文章：已发布和已归档的文章所有人可见，草稿只有作者和编辑可见
+func canViewPost(db *gorm.DB, post *models.Post, viewerID uint) bool {
+	if post.Status != models.PostStatusDraft {
+		return true
+	}
+	return viewerID != 0 && canManage(db, viewerID, post.UserID, PermManagePosts)
+}
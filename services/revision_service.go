@@ -0,0 +1,177 @@
+package services
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// RevisionDiff 两个版本之间正文的差异
+type RevisionDiff struct {
+	PostID    uint   `json:"post_id"`
+	From      int    `json:"from"` // 0 表示空文档
+	To        int    `json:"to"`
+	FromTitle string `json:"from_title"`
+	ToTitle   string `json:"to_title"`
+	Diff      string `json:"diff"` // 统一格式的行级差异，正文相同时为空
+}
+
+// RevisionService 定义文章历史版本相关的业务逻辑接口
+type RevisionService interface {
+	// GetRevisions 获取文章的历史版本，最新的在前
+	GetRevisions(postID, userID uint) ([]models.PostRevision, error)
+	// DiffRevisions 比较文章的两个版本，against 为 0 时与空文档比较
+	DiffRevisions(postID uint, number, against int, userID uint) (*RevisionDiff, error)
+	// RestoreRevision 将文章的标题和正文恢复为指定版本，并记录为新版本
+	RestoreRevision(postID uint, number int, userID uint) (*models.Post, error)
+}
+
+// revisionService 是RevisionService接口的实现
+type revisionService struct{}
+
+// NewRevisionService 创建一个新的RevisionService实例
+func NewRevisionService() RevisionService {
+	return &revisionService{}
+}
+
+// GetRevisions 获取历史版本实现，只有作者和有管理文章权限的用户可以查看
+func (s *revisionService) GetRevisions(postID, userID uint) ([]models.PostRevision, error) {
+	db := config.GetDB()
+	if _, err := manageablePost(db, postID, userID); err != nil {
+		return nil, err
+	}
+
+	var revisions []models.PostRevision
+	if err := db.Preload("User").Where("post_id = ?", postID).Order("number DESC").Find(&revisions).Error; err != nil {
+		return nil, errors.New("failed to fetch revisions")
+	}
+	return revisions, nil
+}
+
+// DiffRevisions 比较版本实现
+func (s *revisionService) DiffRevisions(postID uint, number, against int, userID uint) (*RevisionDiff, error) {
+	db := config.GetDB()
+	if _, err := manageablePost(db, postID, userID); err != nil {
+		return nil, err
+	}
+
+	to, err := findRevision(db, postID, number)
+	if err != nil {
+		return nil, err
+	}
+	from := &models.PostRevision{}
+	if against != 0 {
+		if from, err = findRevision(db, postID, against); err != nil {
+			return nil, err
+		}
+	}
+
+	diff, err := utils.UnifiedDiff(fmt.Sprintf("revision %d", against), fmt.Sprintf("revision %d", number), from.Content, to.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RevisionDiff{
+		PostID:    postID,
+		From:      against,
+		To:        number,
+		FromTitle: from.Title,
+		ToTitle:   to.Title,
+		Diff:      diff,
+	}, nil
+}
+
+// RestoreRevision 回滚版本实现，与更新文章一样重新渲染正文，别名保持不变
+func (s *revisionService) RestoreRevision(postID uint, number int, userID uint) (*models.Post, error) {
+	db := config.GetDB()
+	post, err := manageablePost(db, postID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		revision, err := findRevision(tx, postID, number)
+		if err != nil {
+			return err
+		}
+		post.Title = revision.Title
+		post.Content = revision.Content
+		post.ContentHTML = utils.RenderMarkdown(revision.Content)
+		post.RenderVersion = utils.MarkdownRenderVersion
+		if err := tx.Save(post).Error; err != nil {
+			return errors.New("failed to update post")
+		}
+		if err := GetSearcher().IndexPost(tx, post); err != nil {
+			return err
+		}
+		return recordRevision(tx, post, userID, &revision.Number)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	db.Preload("User").Preload("Tags").Preload("Category").First(post, postID)
+	return post, nil
+}
+
+// manageablePost 获取当前用户可以管理的文章，看不到的草稿视为不存在
+func manageablePost(db *gorm.DB, postID, userID uint) (*models.Post, error) {
+	var post models.Post
+	if err := db.First(&post, postID).Error; err != nil || !canViewPost(db, &post, userID) {
+		return nil, errors.New("post not found")
+	}
+	if !canManage(db, userID, post.UserID, PermManagePosts) {
+		return nil, errors.New("permission denied")
+	}
+	return &post, nil
+}
+
+// findRevision 根据版本号获取文章的历史版本
+func findRevision(db *gorm.DB, postID uint, number int) (*models.PostRevision, error) {
+	var revision models.PostRevision
+	if err := db.Where("post_id = ? AND number = ?", postID, number).First(&revision).Error; err != nil {
+		return nil, errors.New("revision not found")
+	}
+	return &revision, nil
+}
+
+// recordRevision 保存文章当前标题和正文的快照，版本号在文章内递增
+func recordRevision(tx *gorm.DB, post *models.Post, userID uint, restoredFrom *int) error {
+	var last int
+	err := tx.Model(&models.PostRevision{}).Where("post_id = ?", post.ID).
+		Select("COALESCE(MAX(number), 0)").Scan(&last).Error
+	if err != nil {
+		return err
+	}
+	return tx.Create(&models.PostRevision{
+		PostID:       post.ID,
+		Number:       last + 1,
+		UserID:       &userID,
+		Title:        post.Title,
+		Content:      post.Content,
+		RestoredFrom: restoredFrom,
+	}).Error
+}
+
+// ensureBaselineRevision 引入版本历史之前创建的文章没有任何版本，修改前先把原内容保存为第1版
+func ensureBaselineRevision(tx *gorm.DB, post *models.Post) error {
+	var count int64
+	if err := tx.Model(&models.PostRevision{}).Where("post_id = ?", post.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return tx.Create(&models.PostRevision{
+		PostID:    post.ID,
+		Number:    1,
+		UserID:    &post.UserID,
+		Title:     post.Title,
+		Content:   post.Content,
+		CreatedAt: post.UpdatedAt,
+	}).Error
+}
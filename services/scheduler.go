@@ -0,0 +1,98 @@
+package services
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// Job 按固定间隔执行的后台任务
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(now time.Time) error
+}
+
+// Scheduler 进程内的后台任务调度器。每次执行任务前先获取数据库中的租约，
+// 多个实例共用同一个数据库时，同一任务同一时间只会在一个实例上执行
+type Scheduler struct {
+	holder string
+	jobs   []Job
+}
+
+// NewScheduler 创建调度器，实例标识由主机名、进程号和随机串组成
+func NewScheduler() *Scheduler {
+	host, _ := os.Hostname()
+	suffix, _ := utils.GenerateRandomID(4)
+	return &Scheduler{holder: fmt.Sprintf("%s-%d-%s", host, os.Getpid(), suffix)}
+}
+
+// Register 注册后台任务
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start 为每个任务启动一个协程，启动时立即执行一次，ctx取消后停止
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+// loop 按间隔循环执行任务
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunJob(job, time.Now()); err != nil {
+			utils.Error("Scheduled job %s failed: %v", job.Name, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunJob 获取到租约时执行一次任务，返回任务是否执行
+func (s *Scheduler) RunJob(job Job, now time.Time) (bool, error) {
+	acquired, err := AcquireLease(job.Name, s.holder, job.Interval, now)
+	if err != nil || !acquired {
+		return false, err
+	}
+	return true, job.Run(now)
+}
+
+// AcquireLease 获取或续期任务租约。租约不存在、已过期或已由holder持有时获取成功，
+// 有效期为ttl；插入和条件更新都是单条语句，多个实例并发获取时只有一个成功
+func AcquireLease(name, holder string, ttl time.Duration, now time.Time) (bool, error) {
+	db := config.GetDB()
+
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.JobLease{
+		Name:      name,
+		Holder:    holder,
+		ExpiresAt: now.Add(ttl),
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return true, nil
+	}
+
+	result = db.Model(&models.JobLease{}).
+		Where("name = ? AND (holder = ? OR expires_at <= ?)", name, holder, now).
+		Updates(map[string]interface{}{"holder": holder, "expires_at": now.Add(ttl)})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
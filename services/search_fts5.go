@@ -0,0 +1,145 @@
+package services
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"html"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fts5Searcher 基于SQLite FTS5虚拟表的搜索，按bm25排序（标题权重为正文的10倍）
+type fts5Searcher struct{}
+
+// 文章和评论共用一个索引，通过rowid的奇偶区分
+func postRowID(id uint) uint    { return id * 2 }
+func commentRowID(id uint) uint { return id*2 + 1 }
+
+// IndexPost 添加或更新文章的索引
+func (fts5Searcher) IndexPost(tx *gorm.DB, post *models.Post) error {
+	return replaceIndexRow(tx, postRowID(post.ID), post.Title, post.Content)
+}
+
+// IndexComment 添加或更新评论的索引
+func (fts5Searcher) IndexComment(tx *gorm.DB, comment *models.Comment) error {
+	return replaceIndexRow(tx, commentRowID(comment.ID), "", comment.Content)
+}
+
+// RemovePosts 从索引中删除文章
+func (fts5Searcher) RemovePosts(tx *gorm.DB, ids []uint) error {
+	return removeIndexRows(tx, ids, postRowID)
+}
+
+// RemoveComments 从索引中删除评论
+func (fts5Searcher) RemoveComments(tx *gorm.DB, ids []uint) error {
+	return removeIndexRows(tx, ids, commentRowID)
+}
+
+// replaceIndexRow FTS5虚拟表不支持upsert，先删除再插入
+func replaceIndexRow(tx *gorm.DB, rowID uint, title, body string) error {
+	if err := tx.Exec("DELETE FROM "+config.SearchIndexTable+" WHERE rowid = ?", rowID).Error; err != nil {
+		return err
+	}
+	return tx.Exec("INSERT INTO "+config.SearchIndexTable+" (rowid, title, body) VALUES (?, ?, ?)",
+		rowID, searchText(title), searchText(body)).Error
+}
+
+// removeIndexRows 按ID删除索引行
+func removeIndexRows(tx *gorm.DB, ids []uint, rowID func(uint) uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	rowIDs := make([]uint, len(ids))
+	for i, id := range ids {
+		rowIDs[i] = rowID(id)
+	}
+	return tx.Exec("DELETE FROM "+config.SearchIndexTable+" WHERE rowid IN ?", rowIDs).Error
+}
+
+// fts5Row 搜索查询的原始结果
+type fts5Row struct {
+	RowID            uint
+	Score            float64
+	TitleHighlight   string
+	Snippet          string
+	PostID           uint
+	PostTitle        string
+	PostSlug         string
+	PostCreatedAt    time.Time
+	CommentCreatedAt *time.Time
+}
+
+// Search 使用MATCH查询，结果按相关度排序
+func (fts5Searcher) Search(db *gorm.DB, terms []SearchTerm, opts SearchOptions) ([]SearchResult, int64, error) {
+	table := config.SearchIndexTable
+	from := " FROM " + table +
+		" LEFT JOIN comments c ON " + table + ".rowid % 2 = 1 AND c.id = " + table + ".rowid / 2 AND c.deleted_at IS NULL" +
+		" JOIN posts p ON p.id = CASE WHEN " + table + ".rowid % 2 = 1 THEN c.post_id ELSE " + table + ".rowid / 2 END AND p.deleted_at IS NULL" +
+		" WHERE " + table + " MATCH ?"
+	args := []interface{}{fts5MatchExpression(terms)}
+
+	visibility, visibilityArgs := searchVisibility(opts.ViewerID)
+	from += " AND " + visibility
+	args = append(args, visibilityArgs...)
+	switch opts.Type {
+	case SearchTypePost:
+		from += " AND " + table + ".rowid % 2 = 0"
+	case SearchTypeComment:
+		from += " AND " + table + ".rowid % 2 = 1"
+	}
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*)"+from, args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []fts5Row
+	err := db.Raw("SELECT "+table+".rowid AS row_id, -bm25("+table+", 10.0, 1.0) AS score, "+
+		"highlight("+table+", 0, char(2), char(3)) AS title_highlight, "+
+		"snippet("+table+", 1, char(2), char(3), '…', 24) AS snippet, "+
+		"p.id AS post_id, p.title AS post_title, p.slug AS post_slug, "+
+		"p.created_at AS post_created_at, c.created_at AS comment_created_at"+
+		from+" ORDER BY bm25("+table+", 10.0, 1.0), "+table+".rowid DESC LIMIT ? OFFSET ?",
+		append(args, opts.PageSize, searchOffset(opts))...).Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		result := SearchResult{
+			ID:        row.RowID / 2,
+			PostID:    row.PostID,
+			PostSlug:  row.PostSlug,
+			Snippet:   markHighlights(row.Snippet),
+			Score:     row.Score,
+			CreatedAt: row.PostCreatedAt,
+		}
+		if row.RowID%2 == 0 {
+			result.Type = SearchTypePost
+			result.Title = markHighlights(row.TitleHighlight)
+		} else {
+			result.Type = SearchTypeComment
+			result.Title = html.EscapeString(row.PostTitle)
+			if row.CommentCreatedAt != nil {
+				result.CreatedAt = *row.CommentCreatedAt
+			}
+		}
+		results[i] = result
+	}
+	return results, total, nil
+}
+
+// fts5MatchExpression 每个搜索词都用双引号括起来，避免用户输入被解释为FTS5的查询语法
+func fts5MatchExpression(terms []SearchTerm) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = `"` + strings.ReplaceAll(term.Text, `"`, `""`) + `"`
+		if term.Prefix {
+			parts[i] += "*"
+		}
+	}
+	return strings.Join(parts, " ")
+}
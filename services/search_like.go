@@ -0,0 +1,190 @@
+package services
+
+import (
+	"blog-backend/models"
+	"html"
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// 摘要在第一个命中位置前后保留的字符数
+const (
+	snippetBefore = 40
+	snippetLength = 160
+)
+
+// likeSearcher SEARCH_ENGINE=like 时使用的LIKE查询，不维护索引，结果按时间倒序
+type likeSearcher struct{}
+
+func (likeSearcher) IndexPost(tx *gorm.DB, post *models.Post) error          { return nil }
+func (likeSearcher) IndexComment(tx *gorm.DB, comment *models.Comment) error { return nil }
+func (likeSearcher) RemovePosts(tx *gorm.DB, ids []uint) error               { return nil }
+func (likeSearcher) RemoveComments(tx *gorm.DB, ids []uint) error            { return nil }
+
+// likeRow 搜索查询的原始结果
+type likeRow struct {
+	Kind      string
+	RefID     uint
+	PostID    uint
+	PostTitle string
+	PostSlug  string
+	Body      string
+	CreatedAt time.Time
+}
+
+// Search 每个搜索词都要出现在标题或正文中
+func (likeSearcher) Search(db *gorm.DB, terms []SearchTerm, opts SearchOptions) ([]SearchResult, int64, error) {
+	visibility, visibilityArgs := searchVisibility(opts.ViewerID)
+
+	var parts []string
+	var args []interface{}
+	if opts.Type != SearchTypeComment {
+		sql := "SELECT 'post' AS kind, p.id AS ref_id, p.id AS post_id, p.title AS post_title, p.slug AS post_slug, " +
+			"p.content AS body, p.created_at AS created_at FROM posts p WHERE p.deleted_at IS NULL AND " + visibility
+		args = append(args, visibilityArgs...)
+		for _, term := range terms {
+			sql += ` AND (p.title LIKE ? ESCAPE '\' OR p.content LIKE ? ESCAPE '\')`
+			args = append(args, likePattern(term), likePattern(term))
+		}
+		parts = append(parts, sql)
+	}
+	if opts.Type != SearchTypePost {
+		sql := "SELECT 'comment' AS kind, c.id AS ref_id, p.id AS post_id, p.title AS post_title, p.slug AS post_slug, " +
+			"c.content AS body, c.created_at AS created_at FROM comments c JOIN posts p ON p.id = c.post_id " +
+			"WHERE c.deleted_at IS NULL AND NOT c.deleted AND p.deleted_at IS NULL AND " + visibility
+		args = append(args, visibilityArgs...)
+		for _, term := range terms {
+			sql += ` AND c.content LIKE ? ESCAPE '\'`
+			args = append(args, likePattern(term))
+		}
+		parts = append(parts, sql)
+	}
+	union := strings.Join(parts, " UNION ALL ")
+
+	var total int64
+	if err := db.Raw("SELECT COUNT(*) FROM ("+union+")", args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []likeRow
+	err := db.Raw(union+" ORDER BY created_at DESC, ref_id DESC LIMIT ? OFFSET ?",
+		append(args, opts.PageSize, searchOffset(opts))...).Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	results := make([]SearchResult, len(rows))
+	for i, row := range rows {
+		results[i] = SearchResult{
+			Type:      row.Kind,
+			ID:        row.RefID,
+			PostID:    row.PostID,
+			PostSlug:  row.PostSlug,
+			Title:     html.EscapeString(row.PostTitle),
+			Snippet:   likeSnippet(row.Body, terms),
+			CreatedAt: row.CreatedAt,
+		}
+		if row.Kind == SearchTypePost {
+			results[i].Title = highlightTerms([]rune(row.PostTitle), terms)
+		}
+	}
+	return results, total, nil
+}
+
+// likePattern 生成包含匹配的LIKE模式，转义通配符
+func likePattern(term SearchTerm) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term.Text)
+	return "%" + escaped + "%"
+}
+
+// likeSnippet 截取第一个命中位置附近的文本作为摘要
+func likeSnippet(text string, terms []SearchTerm) string {
+	runes := []rune(searchText(text))
+	start := 0
+	if first := firstMatch(lowerRunes(runes), terms); first > snippetBefore {
+		start = first - snippetBefore
+	}
+	end := min(len(runes), start+snippetLength)
+
+	snippet := highlightTerms(runes[start:end], terms)
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// highlightTerms 转义HTML，并用 <mark> 标出所有命中的词（不区分大小写）
+func highlightTerms(runes []rune, terms []SearchTerm) string {
+	lower := lowerRunes(runes)
+	marked := make([]bool, len(runes))
+	for _, term := range terms {
+		needle := lowerRunes([]rune(term.Text))
+		for i := 0; i+len(needle) <= len(lower); i++ {
+			if runesEqual(lower[i:i+len(needle)], needle) {
+				for j := i; j < i+len(needle); j++ {
+					marked[j] = true
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && marked[j] == marked[i] {
+			j++
+		}
+		if marked[i] {
+			b.WriteString("<mark>" + html.EscapeString(string(runes[i:j])) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(string(runes[i:j])))
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// firstMatch 返回第一个命中位置，没有命中（如只命中标题）时返回0
+func firstMatch(lower []rune, terms []SearchTerm) int {
+	first := -1
+	for _, term := range terms {
+		needle := lowerRunes([]rune(term.Text))
+		for i := 0; i+len(needle) <= len(lower); i++ {
+			if runesEqual(lower[i:i+len(needle)], needle) {
+				if first < 0 || i < first {
+					first = i
+				}
+				break
+			}
+		}
+	}
+	return max(first, 0)
+}
+
+// lowerRunes 逐个字符转小写，保持与原文相同的长度以便对应位置
+func lowerRunes(runes []rune) []rune {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
+
+// runesEqual 比较两个字符切片
+func runesEqual(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"errors"
+	"html"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// 搜索结果类型
+const (
+	SearchTypePost    = "post"
+	SearchTypeComment = "comment"
+)
+
+// 搜索限制
+const (
+	maxSearchQueryLength = 200
+	maxSearchTerms       = 10
+)
+
+// 标记高亮位置的控制字符，输出前转换为 <mark> 标签
+const (
+	highlightStart = "\x02"
+	highlightEnd   = "\x03"
+)
+
+// SearchTerm 搜索词：单个词或用双引号括起来的短语，以 * 结尾表示前缀匹配
+type SearchTerm struct {
+	Text   string
+	Prefix bool
+}
+
+// SearchOptions 搜索参数
+type SearchOptions struct {
+	Query    string
+	Type     string // post 或 comment，为空时同时搜索文章和评论
+	ViewerID uint   // 当前用户，非零时结果中包含其本人的草稿和归档文章
+	Page     int
+	PageSize int
+}
+
+// SearchResult 搜索结果，Title 和 Snippet 是已转义的HTML，命中的词用 <mark> 标出
+type SearchResult struct {
+	Type      string    `json:"type"`
+	ID        uint      `json:"id"` // 文章或评论的ID
+	PostID    uint      `json:"post_id"`
+	PostSlug  string    `json:"post_slug"`
+	Title     string    `json:"title"` // 文章标题，评论结果为所属文章的标题
+	Snippet   string    `json:"snippet"`
+	Score     float64   `json:"score"` // 相关度，越大越相关；不支持排序的引擎为0
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Searcher 全文搜索引擎接口，文章和评论写入时在同一事务中更新索引
+type Searcher interface {
+	// IndexPost 添加或更新文章的索引
+	IndexPost(tx *gorm.DB, post *models.Post) error
+	// IndexComment 添加或更新评论的索引
+	IndexComment(tx *gorm.DB, comment *models.Comment) error
+	// RemovePosts 从索引中删除文章
+	RemovePosts(tx *gorm.DB, ids []uint) error
+	// RemoveComments 从索引中删除评论
+	RemoveComments(tx *gorm.DB, ids []uint) error
+	// Search 按解析后的搜索词查询当前用户可见的内容
+	Search(db *gorm.DB, terms []SearchTerm, opts SearchOptions) ([]SearchResult, int64, error)
+}
+
+var (
+	searcherMu      sync.Mutex
+	defaultSearcher Searcher
+)
+
+// GetSearcher 获取当前使用的搜索引擎，未替换时按 SEARCH_ENGINE 配置使用FTS5（默认）或LIKE查询
+func GetSearcher() Searcher {
+	searcherMu.Lock()
+	defer searcherMu.Unlock()
+
+	if defaultSearcher == nil {
+		return sqliteSearcher{}
+	}
+	return defaultSearcher
+}
+
+// SetSearcher 替换搜索引擎，传入nil恢复默认
+func SetSearcher(searcher Searcher) {
+	searcherMu.Lock()
+	defer searcherMu.Unlock()
+	defaultSearcher = searcher
+}
+
+// sqliteSearcher 根据 SEARCH_ENGINE 配置选择FTS5或LIKE实现
+type sqliteSearcher struct{}
+
+// engine 选择具体的搜索实现
+func (sqliteSearcher) engine() Searcher {
+	if config.GetSearchConfig().Engine == config.SearchEngineLike {
+		return likeSearcher{}
+	}
+	return fts5Searcher{}
+}
+
+func (s sqliteSearcher) IndexPost(tx *gorm.DB, post *models.Post) error {
+	return s.engine().IndexPost(tx, post)
+}
+
+func (s sqliteSearcher) IndexComment(tx *gorm.DB, comment *models.Comment) error {
+	return s.engine().IndexComment(tx, comment)
+}
+
+func (s sqliteSearcher) RemovePosts(tx *gorm.DB, ids []uint) error {
+	return s.engine().RemovePosts(tx, ids)
+}
+
+func (s sqliteSearcher) RemoveComments(tx *gorm.DB, ids []uint) error {
+	return s.engine().RemoveComments(tx, ids)
+}
+
+func (s sqliteSearcher) Search(db *gorm.DB, terms []SearchTerm, opts SearchOptions) ([]SearchResult, int64, error) {
+	return s.engine().Search(db, terms, opts)
+}
+
+// SearchService 定义搜索相关的业务逻辑接口
+type SearchService interface {
+	// Search 搜索文章和评论，返回当前页结果和总数
+	Search(opts SearchOptions) ([]SearchResult, int64, error)
+}
+
+// searchService 是SearchService接口的实现
+type searchService struct{}
+
+// NewSearchService 创建一个新的SearchService实例
+func NewSearchService() SearchService {
+	return &searchService{}
+}
+
+// Search 搜索实现
+func (s *searchService) Search(opts SearchOptions) ([]SearchResult, int64, error) {
+	if opts.Type != "" && opts.Type != SearchTypePost && opts.Type != SearchTypeComment {
+		return nil, 0, errors.New("invalid type")
+	}
+	terms, err := ParseSearchQuery(opts.Query)
+	if err != nil {
+		return nil, 0, err
+	}
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	if opts.PageSize < 1 || opts.PageSize > 50 {
+		opts.PageSize = 10
+	}
+
+	results, total, err := GetSearcher().Search(config.GetDB(), terms, opts)
+	if err != nil {
+		return nil, 0, errors.New("search failed")
+	}
+	return results, total, nil
+}
+
+// ParseSearchQuery 解析搜索语句：空白分隔的词之间是“与”的关系，"..." 表示短语，词或短语后的 * 表示前缀匹配
+// 只包含标点的词会被忽略，没有有效的词时返回错误
+func ParseSearchQuery(query string) ([]SearchTerm, error) {
+	if len(query) > maxSearchQueryLength {
+		return nil, errors.New("invalid query")
+	}
+
+	var terms []SearchTerm
+	for rest := strings.TrimSpace(query); rest != ""; rest = strings.TrimSpace(rest) {
+		var text string
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				text, rest = rest[1:], ""
+			} else {
+				text, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexFunc(rest, func(r rune) bool { return unicode.IsSpace(r) || r == '"' })
+			if end < 0 {
+				end = len(rest)
+			}
+			text, rest = rest[:end], rest[end:]
+		}
+
+		term := SearchTerm{Text: strings.Join(strings.Fields(text), " ")}
+		if strings.HasSuffix(term.Text, "*") {
+			term.Text = strings.TrimRight(term.Text, "*")
+			term.Prefix = true
+		} else if strings.HasPrefix(rest, "*") {
+			rest = strings.TrimLeft(rest, "*")
+			term.Prefix = true
+		}
+		if strings.IndexFunc(term.Text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			continue
+		}
+		terms = append(terms, term)
+	}
+
+	if len(terms) == 0 || len(terms) > maxSearchTerms {
+		return nil, errors.New("invalid query")
+	}
+	return terms, nil
+}
+
+// searchText 去掉文本中与高亮标记冲突的控制字符
+func searchText(text string) string {
+	return strings.NewReplacer(highlightStart, "", highlightEnd, "").Replace(text)
+}
+
+// markHighlights 转义HTML，并把高亮标记转换为 <mark> 标签
+func markHighlights(text string) string {
+	text = html.EscapeString(text)
+	return strings.NewReplacer(highlightStart, "<mark>", highlightEnd, "</mark>").Replace(text)
+}
+
+// searchVisibility 搜索结果只包含已发布的文章及其评论，以及当前用户自己的文章
+func searchVisibility(viewerID uint) (string, []interface{}) {
+	if viewerID != 0 {
+		return "(p.status = ? OR p.user_id = ?)", []interface{}{models.PostStatusPublished, viewerID}
+	}
+	return "p.status = ?", []interface{}{models.PostStatusPublished}
+}
+
+// searchOffset 计算分页偏移量
+func searchOffset(opts SearchOptions) int {
+	return (opts.Page - 1) * opts.PageSize
+}
@@ -0,0 +1,332 @@
+package services
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// SeriesService 定义文章系列相关的业务逻辑接口
+type SeriesService interface {
+	// CreateSeries 创建系列
+	CreateSeries(req *models.SeriesRequest, userID uint) (*models.Series, error)
+	// GetSeries 根据ID获取系列及当前用户可见的文章
+	GetSeries(id, viewerID uint) (*models.Series, error)
+	// GetSeriesBySlug 根据别名获取系列及当前用户可见的文章
+	GetSeriesBySlug(slug string, viewerID uint) (*models.Series, error)
+	// UpdateSeries 更新系列信息
+	UpdateSeries(id uint, req *models.SeriesRequest, userID uint) (*models.Series, error)
+	// DeleteSeries 删除系列，系列中的文章保留
+	DeleteSeries(id, userID uint) error
+	// AddPost 将文章添加到系列中的指定位置
+	AddPost(id uint, req *models.SeriesPostRequest, userID uint) (*models.Series, error)
+	// RemovePost 将文章移出系列
+	RemovePost(id, postID, userID uint) (*models.Series, error)
+	// ReorderPosts 按给定顺序重新排列系列中的文章
+	ReorderPosts(id uint, postIDs []uint, userID uint) (*models.Series, error)
+}
+
+// seriesService 是SeriesService接口的实现
+type seriesService struct{}
+
+// NewSeriesService 创建一个新的SeriesService实例
+func NewSeriesService() SeriesService {
+	return &seriesService{}
+}
+
+// CreateSeries 创建系列实现，与发布文章需要相同的权限
+func (s *seriesService) CreateSeries(req *models.SeriesRequest, userID uint) (*models.Series, error) {
+	db := config.GetDB()
+	if !userHasPermission(db, userID, PermCreatePost) {
+		return nil, errors.New("permission denied")
+	}
+
+	series := models.Series{UserID: userID}
+	if err := saveSeries(db, &series, req); err != nil {
+		return nil, err
+	}
+	return s.GetSeries(series.ID, userID)
+}
+
+// GetSeries 根据ID获取系列实现
+func (s *seriesService) GetSeries(id, viewerID uint) (*models.Series, error) {
+	return loadSeries(config.GetDB().Where("id = ?", id), viewerID)
+}
+
+// GetSeriesBySlug 根据别名获取系列实现
+func (s *seriesService) GetSeriesBySlug(slug string, viewerID uint) (*models.Series, error) {
+	return loadSeries(config.GetDB().Where("slug = ?", slug), viewerID)
+}
+
+// loadSeries 按查询条件获取系列，并加载当前用户可见的文章
+func loadSeries(query *gorm.DB, viewerID uint) (*models.Series, error) {
+	var series models.Series
+	if err := query.Preload("User").First(&series).Error; err != nil {
+		return nil, errors.New("series not found")
+	}
+
+	posts, err := seriesPosts(config.GetDB(), series.ID)
+	if err != nil {
+		return nil, errors.New("failed to fetch series")
+	}
+	series.Posts = visibleSeriesPosts(config.GetDB(), posts, viewerID)
+	return &series, nil
+}
+
+// UpdateSeries 更新系列实现
+func (s *seriesService) UpdateSeries(id uint, req *models.SeriesRequest, userID uint) (*models.Series, error) {
+	db := config.GetDB()
+	series, err := manageableSeries(db, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveSeries(db, series, req); err != nil {
+		return nil, err
+	}
+	return s.GetSeries(series.ID, userID)
+}
+
+// DeleteSeries 删除系列实现
+func (s *seriesService) DeleteSeries(id, userID uint) error {
+	db := config.GetDB()
+	series, err := manageableSeries(db, id, userID)
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Post{}).Where("series_id = ?", series.ID).
+			UpdateColumns(map[string]interface{}{"series_id": nil, "series_position": 0}).Error; err != nil {
+			return errors.New("failed to delete series")
+		}
+		if err := tx.Delete(series).Error; err != nil {
+			return errors.New("failed to delete series")
+		}
+		return nil
+	})
+}
+
+// AddPost 添加文章实现，需要同时有权管理系列和文章
+func (s *seriesService) AddPost(id uint, req *models.SeriesPostRequest, userID uint) (*models.Series, error) {
+	db := config.GetDB()
+	series, err := manageableSeries(db, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var post models.Post
+		if err := tx.First(&post, req.PostID).Error; err != nil || !canViewPost(tx, &post, userID) {
+			return errors.New("post not found")
+		}
+		if !canManage(tx, userID, post.UserID, PermManagePosts) {
+			return errors.New("permission denied")
+		}
+		if post.SeriesID != nil {
+			return errors.New("post already in series")
+		}
+
+		posts, err := seriesPosts(tx, series.ID)
+		if err != nil {
+			return err
+		}
+		ids := make([]uint, 0, len(posts)+1)
+		for _, p := range posts {
+			ids = append(ids, p.ID)
+		}
+		position := req.Position
+		if position < 1 || position > len(ids) {
+			position = len(ids) + 1
+		}
+		ids = append(ids[:position-1], append([]uint{post.ID}, ids[position-1:]...)...)
+		return renumberSeries(tx, series.ID, ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSeries(series.ID, userID)
+}
+
+// RemovePost 移出文章实现，系列或文章的管理者都可以操作
+func (s *seriesService) RemovePost(id, postID, userID uint) (*models.Series, error) {
+	db := config.GetDB()
+	var series models.Series
+	if err := db.First(&series, id).Error; err != nil {
+		return nil, errors.New("series not found")
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var post models.Post
+		if err := tx.Where("series_id = ?", series.ID).First(&post, postID).Error; err != nil {
+			return errors.New("post not in series")
+		}
+		if !canManage(tx, userID, series.UserID, PermManagePosts) && !canManage(tx, userID, post.UserID, PermManagePosts) {
+			return errors.New("permission denied")
+		}
+		return detachFromSeries(tx, &post)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSeries(series.ID, userID)
+}
+
+// ReorderPosts 重新排序实现，postIDs必须恰好包含系列中的全部文章
+func (s *seriesService) ReorderPosts(id uint, postIDs []uint, userID uint) (*models.Series, error) {
+	db := config.GetDB()
+	series, err := manageableSeries(db, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		posts, err := seriesPosts(tx, series.ID)
+		if err != nil {
+			return err
+		}
+		if len(posts) != len(postIDs) {
+			return errors.New("invalid order")
+		}
+		remaining := make(map[uint]bool, len(posts))
+		for _, p := range posts {
+			remaining[p.ID] = true
+		}
+		for _, postID := range postIDs {
+			if !remaining[postID] {
+				return errors.New("invalid order")
+			}
+			delete(remaining, postID)
+		}
+		return renumberSeries(tx, series.ID, postIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetSeries(series.ID, userID)
+}
+
+// manageableSeries 获取当前用户有权管理的系列（创建者或拥有管理文章权限的用户）
+func manageableSeries(db *gorm.DB, id, userID uint) (*models.Series, error) {
+	var series models.Series
+	if err := db.First(&series, id).Error; err != nil {
+		return nil, errors.New("series not found")
+	}
+	if !canManage(db, userID, series.UserID, PermManagePosts) {
+		return nil, errors.New("permission denied")
+	}
+	return &series, nil
+}
+
+// saveSeries 校验别名并保存系列
+func saveSeries(db *gorm.DB, series *models.Series, req *models.SeriesRequest) error {
+	slug := req.Slug
+	if slug == "" {
+		slug = utils.Slugify(req.Title)
+	}
+	if !utils.IsValidSlug(slug) {
+		return errors.New("invalid slug")
+	}
+	var count int64
+	db.Model(&models.Series{}).Where("slug = ? AND id <> ?", slug, series.ID).Count(&count)
+	if count > 0 {
+		return errors.New("slug taken")
+	}
+
+	series.Title = req.Title
+	series.Slug = slug
+	series.Description = req.Description
+	if err := db.Save(series).Error; err != nil {
+		return errors.New("failed to save series")
+	}
+	return nil
+}
+
+// seriesPosts 按顺序获取系列中的全部文章（不区分状态）
+func seriesPosts(db *gorm.DB, seriesID uint) ([]models.Post, error) {
+	var posts []models.Post
+	err := db.Where("series_id = ?", seriesID).Order("series_position ASC, id ASC").Find(&posts).Error
+	return posts, err
+}
+
+// visibleSeriesPosts 过滤出当前用户可见的文章
+func visibleSeriesPosts(db *gorm.DB, posts []models.Post, viewerID uint) []models.Post {
+	visible := []models.Post{}
+	for _, post := range posts {
+		if canViewPost(db, &post, viewerID) {
+			visible = append(visible, post)
+		}
+	}
+	return visible
+}
+
+// renumberSeries 按ids的顺序将文章编号为1..n
+func renumberSeries(tx *gorm.DB, seriesID uint, ids []uint) error {
+	for i, id := range ids {
+		err := tx.Model(&models.Post{}).Where("id = ?", id).
+			UpdateColumns(map[string]interface{}{"series_id": seriesID, "series_position": i + 1}).Error
+		if err != nil {
+			return errors.New("failed to update series")
+		}
+	}
+	return nil
+}
+
+// detachFromSeries 将文章移出所在系列，并重新编号系列中剩余的文章
+func detachFromSeries(tx *gorm.DB, post *models.Post) error {
+	if post.SeriesID == nil {
+		return nil
+	}
+	seriesID := *post.SeriesID
+	if err := tx.Unscoped().Model(post).UpdateColumns(map[string]interface{}{"series_id": nil, "series_position": 0}).Error; err != nil {
+		return errors.New("failed to update series")
+	}
+
+	posts, err := seriesPosts(tx, seriesID)
+	if err != nil {
+		return err
+	}
+	ids := make([]uint, 0, len(posts))
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+	return renumberSeries(tx, seriesID, ids)
+}
+
+// seriesNavigation 构建文章详情中的系列信息，序号和上一篇/下一篇只考虑当前用户可见的文章
+func seriesNavigation(db *gorm.DB, post *models.Post, viewerID uint) *models.SeriesNavigation {
+	if post.SeriesID == nil {
+		return nil
+	}
+	var series models.Series
+	if err := db.First(&series, *post.SeriesID).Error; err != nil {
+		return nil
+	}
+	posts, err := seriesPosts(db, series.ID)
+	if err != nil {
+		return nil
+	}
+	visible := visibleSeriesPosts(db, posts, viewerID)
+
+	nav := &models.SeriesNavigation{
+		ID:    series.ID,
+		Title: series.Title,
+		Slug:  series.Slug,
+		Total: len(visible),
+	}
+	for i, p := range visible {
+		if p.ID != post.ID {
+			continue
+		}
+		nav.Position = i + 1
+		if i > 0 {
+			nav.Previous = &models.PostRef{ID: visible[i-1].ID, Title: visible[i-1].Title, Slug: visible[i-1].Slug}
+		}
+		if i < len(visible)-1 {
+			nav.Next = &models.PostRef{ID: visible[i+1].ID, Title: visible[i+1].Title, Slug: visible[i+1].Slug}
+		}
+	}
+	return nav
+}
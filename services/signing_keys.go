@@ -0,0 +1,237 @@
+package services
+
+import (
+	"blog-backend/config"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey 一把JWT密钥，Private为空时只能用于验证（已轮换下线的旧密钥）
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+}
+
+// KeyRing JWT密钥集合，active用于签发，keys中的全部密钥都可用于验证
+type KeyRing struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+	secret []byte
+}
+
+// JWK 公开的JSON Web Key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+var (
+	keyRingMu      sync.RWMutex
+	defaultKeyRing *KeyRing
+)
+
+// GetKeyRing 获取当前的密钥集合，首次调用时根据配置加载
+// 加载失败时返回空集合（无法签发也无法验证），启动时应先调用ReloadKeyRing确认配置正确
+func GetKeyRing() *KeyRing {
+	keyRingMu.RLock()
+	ring := defaultKeyRing
+	keyRingMu.RUnlock()
+	if ring != nil {
+		return ring
+	}
+
+	ring, err := LoadKeyRing(config.GetJWTConfig())
+	if err != nil {
+		return &KeyRing{keys: map[string]*SigningKey{}}
+	}
+	keyRingMu.Lock()
+	defer keyRingMu.Unlock()
+	if defaultKeyRing == nil {
+		defaultKeyRing = ring
+	}
+	return defaultKeyRing
+}
+
+// ReloadKeyRing 重新读取密钥目录，轮换密钥后调用即可生效
+func ReloadKeyRing() error {
+	ring, err := LoadKeyRing(config.GetJWTConfig())
+	if err != nil {
+		return err
+	}
+	keyRingMu.Lock()
+	defaultKeyRing = ring
+	keyRingMu.Unlock()
+	return nil
+}
+
+// LoadKeyRing 根据配置加载密钥集合，未配置密钥目录时使用HMAC密钥，HMAC密钥为空或为公开的默认值时返回错误
+// 配置密钥目录后不再接受HMAC令牌，切换时已签发的访问令牌失效，客户端使用刷新令牌换取新令牌即可
+func LoadKeyRing(cfg config.JWTConfig) (*KeyRing, error) {
+	if cfg.KeysDir == "" {
+		if cfg.SecretKey == "" || cfg.SecretKey == config.InsecureJWTSecret {
+			return nil, errors.New("JWT_KEYS_DIR or a non-default JWT_SECRET must be configured")
+		}
+		return newHMACKeyRing(cfg.SecretKey), nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(cfg.KeysDir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	ring := &KeyRing{keys: make(map[string]*SigningKey)}
+	var lastSigner *SigningKey
+	for _, file := range files {
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := loadSigningKey(kid, file)
+		if err != nil {
+			return nil, fmt.Errorf("load key %s: %w", kid, err)
+		}
+		ring.keys[kid] = key
+		if key.Private != nil {
+			lastSigner = key
+		}
+	}
+
+	// 未指定时使用文件名排序最后的私钥，密钥文件可以按日期命名
+	if cfg.ActiveKeyID != "" {
+		ring.active = ring.keys[cfg.ActiveKeyID]
+		if ring.active == nil || ring.active.Private == nil {
+			return nil, fmt.Errorf("active key %s not found", cfg.ActiveKeyID)
+		}
+	} else {
+		ring.active = lastSigner
+	}
+	if ring.active == nil {
+		return nil, errors.New("no private key found in " + cfg.KeysDir)
+	}
+
+	return ring, nil
+}
+
+// newHMACKeyRing 创建只使用HMAC密钥的集合
+func newHMACKeyRing(secret string) *KeyRing {
+	return &KeyRing{keys: map[string]*SigningKey{}, secret: []byte(secret)}
+}
+
+// loadSigningKey 读取PEM文件，支持RSA和Ed25519私钥，以及只用于验证的公钥
+func loadSigningKey(kid, file string) (*SigningKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid pem file")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, errors.New("unsupported pem type " + block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+	return key, nil
+}
+
+// Sign 使用当前密钥签发令牌，非对称密钥会在头部写入kid
+func (r *KeyRing) Sign(claims jwt.MapClaims) (string, error) {
+	if r.active == nil {
+		if len(r.secret) == 0 {
+			return "", errors.New("no signing key")
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(r.secret)
+	}
+	token := jwt.NewWithClaims(r.active.Method, claims)
+	token.Header["kid"] = r.active.ID
+	return token.SignedString(r.active.Private)
+}
+
+// Keyfunc 根据令牌头部的kid选择验证密钥，没有kid的令牌只在HMAC模式下接受
+func (r *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if token.Method.Alg() != jwt.SigningMethodHS256.Alg() || len(r.secret) == 0 {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return r.secret, nil
+	}
+
+	key := r.keys[kid]
+	if key == nil || key.Method.Alg() != token.Method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return key.Public, nil
+}
+
+// JWKS 返回全部验证公钥，供其它服务自行验证令牌
+func (r *KeyRing) JWKS() []JWK {
+	kids := make([]string, 0, len(r.keys))
+	for kid := range r.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := make([]JWK, 0, len(kids))
+	for _, kid := range kids {
+		key := r.keys[kid]
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		jwks = append(jwks, jwk)
+	}
+	return jwks
+}
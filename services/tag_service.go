@@ -0,0 +1,104 @@
+package services
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// TagWithCount 带已发布文章数的标签
+type TagWithCount struct {
+	models.Tag
+	PostCount int64 `json:"post_count"`
+}
+
+// TagService 定义标签相关的业务逻辑接口
+type TagService interface {
+	// GetTags 获取有已发布文章的标签及文章数，按文章数降序排列
+	GetTags() ([]TagWithCount, error)
+	// GetTagBySlug 根据别名获取标签
+	GetTagBySlug(slug string) (*models.Tag, error)
+}
+
+// tagService 是TagService接口的实现
+type tagService struct{}
+
+// NewTagService 创建一个新的TagService实例
+func NewTagService() TagService {
+	return &tagService{}
+}
+
+// GetTags 获取标签列表实现，只统计已发布的文章，避免泄露草稿使用的标签
+func (s *tagService) GetTags() ([]TagWithCount, error) {
+	var tags []TagWithCount
+	err := config.GetDB().Model(&models.Tag{}).
+		Select("tags.*, COUNT(posts.id) AS post_count").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.status = ? AND posts.deleted_at IS NULL", models.PostStatusPublished).
+		Group("tags.id").
+		Order("post_count DESC, tags.name ASC").
+		Scan(&tags).Error
+	if err != nil {
+		return nil, errors.New("failed to fetch tags")
+	}
+	return tags, nil
+}
+
+// GetTagBySlug 根据别名获取标签实现
+func (s *tagService) GetTagBySlug(slug string) (*models.Tag, error) {
+	var tag models.Tag
+	if err := config.GetDB().Where("slug = ?", slug).First(&tag).Error; err != nil {
+		return nil, errors.New("tag not found")
+	}
+	return &tag, nil
+}
+
+// normalizeTagName 去掉标签名首尾的空白并合并中间的连续空白
+func normalizeTagName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// resolveTags 将标签名规范化并按别名去重（Go、go、GO 视为同一个标签），不存在的标签自动创建
+func resolveTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	tags := []models.Tag{}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = normalizeTagName(name)
+		slug := utils.Slugify(name)
+		if slug == "" {
+			return nil, errors.New("invalid tag")
+		}
+		if seen[slug] {
+			continue
+		}
+		seen[slug] = true
+
+		var tag models.Tag
+		if err := tx.Where(models.Tag{Slug: slug}).Attrs(models.Tag{Name: name}).FirstOrCreate(&tag).Error; err != nil {
+			return nil, errors.New("failed to save tags")
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// replacePostTags 用给定的标签名替换文章的全部标签，并清理不再使用的标签
+func replacePostTags(tx *gorm.DB, post *models.Post, names []string) error {
+	tags, err := resolveTags(tx, names)
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(post).Association("Tags").Replace(tags); err != nil {
+		return errors.New("failed to save tags")
+	}
+	return cleanupUnusedTags(tx)
+}
+
+// cleanupUnusedTags 删除没有任何文章使用的标签
+func cleanupUnusedTags(tx *gorm.DB) error {
+	return tx.Where("id NOT IN (?)", tx.Table("post_tags").Select("tag_id")).Delete(&models.Tag{}).Error
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// AccessClaims 访问令牌中携带的用户信息
+type AccessClaims struct {
+	UserID    uint
+	Username  string
+	Role      string
+	SessionID string
+}
+
+// TokenService 定义访问令牌与刷新令牌相关的业务逻辑接口
+type TokenService interface {
+	// IssueTokens 为用户创建新会话并签发令牌
+	IssueTokens(user *models.User) (*models.TokenPair, error)
+	// RefreshTokens 使用刷新令牌轮换出新的令牌
+	RefreshTokens(refreshToken string) (*models.TokenPair, error)
+	// RevokeSession 吊销刷新令牌所属的整个会话
+	RevokeSession(refreshToken string) error
+	// RevokeUserSessions 吊销用户的所有会话
+	RevokeUserSessions(userID uint) error
+	// ParseAccessToken 校验访问令牌并确认其会话仍然有效
+	ParseAccessToken(tokenString string) (*AccessClaims, error)
+	// IssueMFAToken 签发两步验证登录使用的短期挑战令牌
+	IssueMFAToken(user *models.User) (string, error)
+	// ParseMFAToken 校验挑战令牌，返回用户ID
+	ParseMFAToken(tokenString string) (uint, error)
+}
+
+// tokenService 是TokenService接口的实现
+type tokenService struct{}
+
+// NewTokenService 创建一个新的TokenService实例
+func NewTokenService() TokenService {
+	return &tokenService{}
+}
+
+// IssueTokens 签发令牌实现
+func (s *tokenService) IssueTokens(user *models.User) (*models.TokenPair, error) {
+	jwtConfig := config.GetJWTConfig()
+	sessionID, err := utils.GenerateRandomID(16)
+	if err != nil {
+		return nil, errors.New("failed to generate token")
+	}
+
+	session := models.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(jwtConfig.RefreshExpiresIn),
+	}
+
+	var pair *models.TokenPair
+	err = config.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+		var err error
+		pair, err = s.issuePair(tx, user, sessionID)
+		return err
+	})
+	if err != nil {
+		return nil, errors.New("failed to generate token")
+	}
+
+	return pair, nil
+}
+
+// RefreshTokens 刷新令牌实现
+// 每个刷新令牌只能使用一次，已使用过的令牌再次出现说明令牌可能被盗用，此时吊销整个会话
+func (s *tokenService) RefreshTokens(refreshToken string) (*models.TokenPair, error) {
+	db := config.GetDB()
+	now := time.Now()
+
+	var token models.RefreshToken
+	if err := db.Where("token_hash = ?", utils.HashToken(refreshToken)).First(&token).Error; err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	var session models.Session
+	if err := db.First(&session, "id = ?", token.SessionID).Error; err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+	if session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	if token.UsedAt != nil {
+		s.revokeSession(db, session.ID)
+		return nil, errors.New("refresh token reused")
+	}
+	if !token.ExpiresAt.After(now) {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	var user models.User
+	if err := db.First(&user, token.UserID).Error; err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	var pair *models.TokenPair
+	reused := false
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 条件更新保证并发请求中只有一个能够使用该令牌
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			reused = true
+			return errors.New("refresh token reused")
+		}
+
+		if err := tx.Model(&session).Update("expires_at", now.Add(config.GetJWTConfig().RefreshExpiresIn)).Error; err != nil {
+			return err
+		}
+
+		var err error
+		pair, err = s.issuePair(tx, &user, session.ID)
+		return err
+	})
+	if reused {
+		s.revokeSession(db, session.ID)
+		return nil, errors.New("refresh token reused")
+	}
+	if err != nil {
+		return nil, errors.New("failed to generate token")
+	}
+
+	return pair, nil
+}
+
+// RevokeSession 吊销会话实现
+func (s *tokenService) RevokeSession(refreshToken string) error {
+	db := config.GetDB()
+
+	var token models.RefreshToken
+	if err := db.Where("token_hash = ?", utils.HashToken(refreshToken)).First(&token).Error; err != nil {
+		return errors.New("invalid refresh token")
+	}
+
+	if err := s.revokeSession(db, token.SessionID); err != nil {
+		return errors.New("failed to revoke session")
+	}
+
+	return nil
+}
+
+// RevokeUserSessions 吊销用户所有会话实现
+func (s *tokenService) RevokeUserSessions(userID uint) error {
+	err := config.GetDB().Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+	if err != nil {
+		return errors.New("failed to revoke sessions")
+	}
+	return nil
+}
+
+// ParseAccessToken 解析访问令牌实现
+func (s *tokenService) ParseAccessToken(tokenString string) (*AccessClaims, error) {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	// 其它用途的令牌（如两步验证挑战令牌）不能作为访问令牌使用
+	if typ, ok := claims["typ"].(string); ok && typ != "access" {
+		return nil, errors.New("invalid token claims")
+	}
+	id, ok1 := claims["id"].(float64)
+	sessionID, ok2 := claims["sid"].(string)
+	if !ok1 || !ok2 {
+		return nil, errors.New("invalid token claims")
+	}
+
+	// 会话被吊销（注销、令牌盗用）后，其下的访问令牌立即失效
+	// 角色以数据库为准，降级后不必等待令牌过期
+	var user models.User
+	err = config.GetDB().Model(&models.User{}).
+		Joins("JOIN sessions ON sessions.user_id = users.id").
+		Where("sessions.id = ? AND sessions.user_id = ? AND sessions.revoked_at IS NULL AND sessions.expires_at > ?",
+			sessionID, uint(id), time.Now()).
+		First(&user).Error
+	if err != nil {
+		return nil, errors.New("session revoked")
+	}
+
+	return &AccessClaims{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		SessionID: sessionID,
+	}, nil
+}
+
+// IssueMFAToken 签发挑战令牌实现
+func (s *tokenService) IssueMFAToken(user *models.User) (string, error) {
+	now := time.Now()
+	tokenString, err := GetKeyRing().Sign(jwt.MapClaims{
+		"id":  user.ID,
+		"typ": "mfa",
+		"iat": now.Unix(),
+		"exp": now.Add(config.GetAccountConfig().MFATokenTTL).Unix(),
+	})
+	if err != nil {
+		return "", errors.New("failed to generate token")
+	}
+	return tokenString, nil
+}
+
+// ParseMFAToken 解析挑战令牌实现
+func (s *tokenService) ParseMFAToken(tokenString string) (uint, error) {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		return 0, err
+	}
+	typ, _ := claims["typ"].(string)
+	id, ok := claims["id"].(float64)
+	if typ != "mfa" || !ok {
+		return 0, errors.New("invalid token claims")
+	}
+	return uint(id), nil
+}
+
+// parse 校验令牌签名和有效期，返回其声明
+func (s *tokenService) parse(tokenString string) (jwt.MapClaims, error) {
+	// 签名方法由kid对应的密钥决定，不信任令牌自己声明的算法
+	token, err := jwt.Parse(tokenString, GetKeyRing().Keyfunc)
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	return claims, nil
+}
+
+// issuePair 在指定会话下签发访问令牌和新的刷新令牌
+func (s *tokenService) issuePair(tx *gorm.DB, user *models.User, sessionID string) (*models.TokenPair, error) {
+	jwtConfig := config.GetJWTConfig()
+	now := time.Now()
+
+	accessToken, err := GetKeyRing().Sign(jwt.MapClaims{
+		"id":       user.ID,
+		"username": user.Username,
+		"role":     user.Role,
+		"sid":      sessionID,
+		"typ":      "access",
+		"iat":      now.Unix(),
+		"exp":      now.Add(jwtConfig.ExpiresIn).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	record := models.RefreshToken{
+		SessionID: sessionID,
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(refreshToken),
+		ExpiresAt: now.Add(jwtConfig.RefreshExpiresIn),
+	}
+	if err := tx.Create(&record).Error; err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(jwtConfig.ExpiresIn.Seconds()),
+	}, nil
+}
+
+// revokeSession 将会话标记为已吊销
+func (s *tokenService) revokeSession(db *gorm.DB, sessionID string) error {
+	return db.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
+}
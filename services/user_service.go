@@ -3,38 +3,92 @@ package services
 import (
 	"blog-backend/config"
 	"blog-backend/models"
+	"blog-backend/utils"
 	"errors"
+	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+// LoginResult 登录结果，开启两步验证时只返回挑战令牌
+type LoginResult struct {
+	User     *models.User
+	Tokens   *models.TokenPair
+	MFAToken string
+}
+
+// PublicProfile 对外公开的用户资料，不包含邮箱等隐私信息
+type PublicProfile struct {
+	ID           uint      `json:"id"`
+	Username     string    `json:"username"`
+	DisplayName  string    `json:"display_name"`
+	Bio          string    `json:"bio"`
+	Website      string    `json:"website"`
+	AvatarURL    string    `json:"avatar_url"`
+	PostCount    int64     `json:"post_count"`
+	CommentCount int64     `json:"comment_count"`
+	JoinedAt     time.Time `json:"joined_at"`
+}
+
 // UserService 定义用户相关的业务逻辑接口
 type UserService interface {
 	// Register 用户注册
 	Register(req *models.RegisterRequest) error
-	// Login 用户登录，返回用户信息和JWT令牌
-	Login(req *models.LoginRequest) (*models.User, string, error)
+	// Login 用户登录，返回用户信息和令牌；clientIP用于限制失败次数
+	Login(req *models.LoginRequest, clientIP string) (*LoginResult, error)
 	// GetUserByID 根据ID获取用户信息
 	GetUserByID(id uint) (*models.User, error)
 	// GetUserByUsername 根据用户名获取用户信息
 	GetUserByUsername(username string) (*models.User, error)
+	// GetPublicProfile 获取用户的公开资料
+	GetPublicProfile(username string) (*PublicProfile, error)
+	// ListUsers 分页获取用户列表
+	ListUsers(page, pageSize int) ([]models.User, int64, error)
+	// SetRole 授予用户角色
+	SetRole(id uint, role string) (*models.User, error)
+	// RevokeRole 撤销用户角色，恢复为默认角色
+	RevokeRole(id uint) (*models.User, error)
+	// UnlockLogin 解除用户（以及可选的IP）的登录锁定
+	UnlockLogin(id uint, ip string) error
+	// RequestPasswordReset 生成密码重置令牌并发送邮件
+	RequestPasswordReset(email string) error
+	// ResetPassword 使用重置令牌设置新密码
+	ResetPassword(token, newPassword string) error
+	// VerifyEmail 使用验证令牌确认邮箱
+	VerifyEmail(token string) (*models.User, error)
+	// ResendVerification 重新发送邮箱验证邮件
+	ResendVerification(userID uint) error
+	// UpdateProfile 更新个人资料，replace为true时未提供的字段会被清空
+	UpdateProfile(userID uint, req *models.ProfileRequest, replace bool) (*models.User, error)
+	// ChangePassword 校验当前密码后修改密码，吊销所有会话并为当前客户端签发新令牌
+	ChangePassword(userID uint, currentPassword, newPassword string) (*models.TokenPair, error)
+	// RequestEmailChange 校验密码后向新邮箱发送验证邮件，验证通过后新邮箱才生效
+	RequestEmailChange(userID uint, email, password string) error
 }
 
 // userService 是UserService接口的实现
-type userService struct{}
+type userService struct {
+	tokens TokenService
+	guard  *LoginGuard
+}
 
 // NewUserService 创建一个新的UserService实例
 func NewUserService() UserService {
-	return &userService{}
+	return &userService{tokens: NewTokenService(), guard: NewLoginGuard()}
 }
 
 // Register 用户注册实现
 func (s *userService) Register(req *models.RegisterRequest) error {
-	// 检查用户名是否已存在
+	// 检查用户名是否已存在（注销用户的占位用户名保留不可注册）
 	var existingUser models.User
 	db := config.GetDB()
+	if strings.EqualFold(req.Username, config.GetAccountConfig().DeletedUsername) {
+		return errors.New("username already exists")
+	}
 	if err := db.Where("username = ?", req.Username).First(&existingUser).Error; err == nil {
 		return errors.New("username already exists")
 	}
@@ -50,48 +104,67 @@ func (s *userService) Register(req *models.RegisterRequest) error {
 		return errors.New("failed to hash password")
 	}
 
-	// 创建新用户
+	// 创建新用户，注册时从不授予管理员角色，初始管理员在启动时由PromoteBootstrapAdmins指定
 	user := models.User{
 		Username: req.Username,
 		Password: string(hashedPassword),
 		Email:    req.Email,
+		Role:     config.GetRBACConfig().DefaultRole,
 	}
 
 	if err := db.Create(&user).Error; err != nil {
 		return errors.New("failed to create user")
 	}
 
+	// 新账户处于未验证状态，发送验证邮件失败时用户可以稍后重新发送
+	if err := s.sendVerification(&user, user.Email); err != nil {
+		utils.Error("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
 	return nil
 }
 
 // Login 用户登录实现
-func (s *userService) Login(req *models.LoginRequest) (*models.User, string, error) {
+func (s *userService) Login(req *models.LoginRequest, clientIP string) (*LoginResult, error) {
+	// 失败次数过多时直接拒绝，不再校验密码；否则预先记录本次失败
+	if err := s.guard.Reserve(req.Username, clientIP); err != nil {
+		return nil, err
+	}
+
 	// 查找用户
 	var user models.User
 	db := config.GetDB()
 	if err := db.Where("username = ?", req.Username).First(&user).Error; err != nil {
-		return nil, "", errors.New("invalid username or password")
+		return nil, errors.New("invalid username or password")
 	}
 
 	// 验证密码
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, "", errors.New("invalid username or password")
+		return nil, errors.New("invalid username or password")
 	}
+	s.guard.Succeed(req.Username, clientIP)
 
-	// 生成JWT
-	jwtConfig := config.GetJWTConfig()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"id":       user.ID,
-		"username": user.Username,
-		"exp":      time.Now().Add(jwtConfig.ExpiresIn).Unix(),
-	})
+	return startLogin(s.tokens, &user)
+}
+
+// startLogin 身份校验通过后签发令牌
+// 开启两步验证的用户只获得挑战令牌，需凭挑战令牌和验证码换取正式令牌
+func startLogin(tokens TokenService, user *models.User) (*LoginResult, error) {
+	if user.TOTPEnabled {
+		mfaToken, err := tokens.IssueMFAToken(user)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{User: user, MFAToken: mfaToken}, nil
+	}
 
-	tokenString, err := token.SignedString([]byte(jwtConfig.SecretKey))
+	// 创建会话并签发令牌
+	pair, err := tokens.IssueTokens(user)
 	if err != nil {
-		return nil, "", errors.New("failed to generate token")
+		return nil, err
 	}
 
-	return &user, tokenString, nil
+	return &LoginResult{User: user, Tokens: pair}, nil
 }
 
 // GetUserByID 根据ID获取用户信息实现
@@ -112,4 +185,443 @@ func (s *userService) GetUserByUsername(username string) (*models.User, error) {
 		return nil, errors.New("user not found")
 	}
 	return &user, nil
-}
\ No newline at end of file
+}
+
+// GetPublicProfile 获取公开资料实现
+func (s *userService) GetPublicProfile(username string) (*PublicProfile, error) {
+	user, err := s.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &PublicProfile{
+		ID:          user.ID,
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		Website:     user.Website,
+		AvatarURL:   user.AvatarURL,
+		JoinedAt:    user.CreatedAt,
+	}
+	db := config.GetDB()
+	// 两个计数都只统计已发布文章，草稿和定时文章下的评论同样不公开
+	db.Model(&models.Post{}).Where("user_id = ? AND status = ?", user.ID, models.PostStatusPublished).Count(&profile.PostCount)
+	db.Model(&models.Comment{}).
+		Joins("JOIN posts ON posts.id = comments.post_id AND posts.status = ? AND posts.deleted_at IS NULL", models.PostStatusPublished).
+		Where("comments.user_id = ? AND comments.deleted = ?", user.ID, false).
+		Count(&profile.CommentCount)
+
+	return profile, nil
+}
+
+// ListUsers 分页获取用户列表实现
+func (s *userService) ListUsers(page, pageSize int) ([]models.User, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	var users []models.User
+	var total int64
+	db := config.GetDB()
+	db.Model(&models.User{}).Count(&total)
+	if err := db.Order("id ASC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&users).Error; err != nil {
+		return nil, 0, errors.New("failed to fetch users")
+	}
+
+	return users, total, nil
+}
+
+// SetRole 授予用户角色实现
+func (s *userService) SetRole(id uint, role string) (*models.User, error) {
+	if !IsValidRole(role) {
+		return nil, errors.New("invalid role")
+	}
+	return s.changeRole(id, role)
+}
+
+// RevokeRole 撤销用户角色实现
+func (s *userService) RevokeRole(id uint) (*models.User, error) {
+	return s.changeRole(id, config.GetRBACConfig().DefaultRole)
+}
+
+// UnlockLogin 解除登录锁定实现
+func (s *userService) UnlockLogin(id uint, ip string) error {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.guard.Unlock(user.Username, ip); err != nil {
+		return errors.New("failed to unlock user")
+	}
+	return nil
+}
+
+// changeRole 修改用户角色，并吊销其会话使令牌中的旧角色立即失效
+func (s *userService) changeRole(id uint, role string) (*models.User, error) {
+	db := config.GetDB()
+	var user models.User
+	if err := db.First(&user, id).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+	if user.Role == role {
+		return &user, nil
+	}
+
+	// 只在角色未被并发修改时更新，不允许移除最后一个管理员
+	query := db.Model(&models.User{}).Where("id = ? AND role = ?", user.ID, user.Role)
+	if user.Role == models.RoleAdmin {
+		query = query.Scopes(otherAdminRemains)
+	}
+	result := query.Update("role", role)
+	if result.Error != nil {
+		return nil, errors.New("failed to update role")
+	}
+	if result.RowsAffected == 0 {
+		if user.Role == models.RoleAdmin {
+			return nil, errors.New("cannot remove the last admin")
+		}
+		return nil, errors.New("failed to update role")
+	}
+	user.Role = role
+	if err := s.tokens.RevokeUserSessions(user.ID); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// RequestPasswordReset 申请密码重置实现
+// 邮箱不存在时同样返回成功，避免泄露账户是否存在
+func (s *userService) RequestPasswordReset(email string) error {
+	db := config.GetDB()
+	var user models.User
+	if err := db.Where("email = ?", email).First(&user).Error; err != nil {
+		return nil
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return errors.New("failed to generate token")
+	}
+
+	accountConfig := config.GetAccountConfig()
+	now := time.Now()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// 新令牌生成后，之前未使用的令牌全部作废
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: now.Add(accountConfig.PasswordResetTTL),
+		}).Error
+	})
+	if err != nil {
+		return errors.New("failed to create reset token")
+	}
+
+	link := fmt.Sprintf("%s/reset-password?token=%s", accountConfig.BaseURL, url.QueryEscape(token))
+	err = GetMailer().Send(&Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to reset your password. It expires in %s.\n\n%s\n\nIf you did not request a password reset, you can ignore this email.\n",
+			user.Username, accountConfig.PasswordResetTTL, link),
+	})
+	if err != nil {
+		return errors.New("failed to send email")
+	}
+
+	return nil
+}
+
+// ResetPassword 重置密码实现，成功后吊销该用户的所有会话
+func (s *userService) ResetPassword(token, newPassword string) error {
+	db := config.GetDB()
+	now := time.Now()
+
+	var resetToken models.PasswordResetToken
+	if err := db.Where("token_hash = ?", utils.HashToken(token)).First(&resetToken).Error; err != nil {
+		return errors.New("invalid or expired token")
+	}
+	if resetToken.UsedAt != nil || !resetToken.ExpiresAt.After(now) {
+		return errors.New("invalid or expired token")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// 条件更新保证令牌只能使用一次
+		result := tx.Model(&models.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", resetToken.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("invalid or expired token")
+		}
+		return tx.Model(&models.User{}).Where("id = ?", resetToken.UserID).Update("password", string(hashedPassword)).Error
+	})
+	if err != nil {
+		if err.Error() == "invalid or expired token" {
+			return err
+		}
+		return errors.New("failed to reset password")
+	}
+
+	return s.tokens.RevokeUserSessions(resetToken.UserID)
+}
+
+// VerifyEmail 验证邮箱实现
+func (s *userService) VerifyEmail(token string) (*models.User, error) {
+	db := config.GetDB()
+	now := time.Now()
+
+	var verification models.EmailVerificationToken
+	if err := db.Where("token_hash = ?", utils.HashToken(token)).First(&verification).Error; err != nil {
+		return nil, errors.New("invalid or expired token")
+	}
+	if verification.UsedAt != nil || !verification.ExpiresAt.After(now) {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	var user models.User
+	if err := db.First(&user, verification.UserID).Error; err != nil {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	// 令牌中的邮箱与当前邮箱不同说明是修改邮箱，验证通过后才替换
+	if user.Email != verification.Email {
+		var count int64
+		db.Model(&models.User{}).Where("email = ? AND id <> ?", verification.Email, user.ID).Count(&count)
+		if count > 0 {
+			return nil, errors.New("email already exists")
+		}
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.EmailVerificationToken{}).
+			Where("id = ? AND used_at IS NULL", verification.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("invalid or expired token")
+		}
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"email":             verification.Email,
+			"email_verified":    true,
+			"email_verified_at": now,
+		}).Error
+	})
+	if err != nil {
+		if err.Error() == "invalid or expired token" {
+			return nil, err
+		}
+		return nil, errors.New("failed to verify email")
+	}
+
+	user.Email = verification.Email
+	return &user, nil
+}
+
+// ResendVerification 重新发送验证邮件实现，两次发送之间至少间隔配置的时间
+func (s *userService) ResendVerification(userID uint) error {
+	db := config.GetDB()
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return errors.New("user not found")
+	}
+
+	// 有未完成的邮箱修改时重发到新邮箱，否则重发到当前邮箱
+	email := user.Email
+	var pending models.EmailVerificationToken
+	err := db.Where("user_id = ? AND email <> ? AND used_at IS NULL AND expires_at > ?", user.ID, user.Email, time.Now()).
+		Order("created_at DESC").First(&pending).Error
+	if err == nil {
+		email = pending.Email
+	} else if user.EmailVerified {
+		return errors.New("email already verified")
+	}
+
+	var last models.EmailVerificationToken
+	if err := db.Where("user_id = ?", user.ID).Order("created_at DESC").First(&last).Error; err == nil {
+		interval := config.GetAccountConfig().VerificationInterval
+		if wait := last.CreatedAt.Add(interval).Sub(time.Now()); wait > 0 {
+			return &RateLimitError{Message: "verification email sent too recently", RetryAfter: wait}
+		}
+	}
+
+	return s.sendVerification(&user, email)
+}
+
+// UpdateProfile 更新个人资料实现
+func (s *userService) UpdateProfile(userID uint, req *models.ProfileRequest, replace bool) (*models.User, error) {
+	db := config.GetDB()
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	fields := []struct {
+		column string
+		value  *string
+		isURL  bool
+	}{
+		{"display_name", req.DisplayName, false},
+		{"bio", req.Bio, false},
+		{"website", req.Website, true},
+		{"avatar_url", req.AvatarURL, true},
+	}
+
+	updates := make(map[string]interface{})
+	for _, field := range fields {
+		if field.value == nil {
+			if replace {
+				updates[field.column] = ""
+			}
+			continue
+		}
+		value := strings.TrimSpace(*field.value)
+		if field.isURL && value != "" && !isHTTPURL(value) {
+			return nil, errors.New("invalid url")
+		}
+		updates[field.column] = value
+	}
+
+	if len(updates) > 0 {
+		if err := db.Model(&user).Updates(updates).Error; err != nil {
+			return nil, errors.New("failed to update profile")
+		}
+	}
+
+	return s.GetUserByID(userID)
+}
+
+// ChangePassword 修改密码实现
+func (s *userService) ChangePassword(userID uint, currentPassword, newPassword string) (*models.TokenPair, error) {
+	db := config.GetDB()
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	// 当前密码的猜测与登录共用失败计数
+	if err := s.guard.Reserve(user.Username, ""); err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		return nil, errors.New("invalid current password")
+	}
+	s.guard.Succeed(user.Username, "")
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.New("failed to hash password")
+	}
+	if err := db.Model(&user).Update("password", string(hashedPassword)).Error; err != nil {
+		return nil, errors.New("failed to change password")
+	}
+
+	// 其它设备上的会话全部失效，当前客户端使用新签发的令牌继续访问
+	if err := s.tokens.RevokeUserSessions(user.ID); err != nil {
+		return nil, err
+	}
+	return s.tokens.IssueTokens(&user)
+}
+
+// RequestEmailChange 申请修改邮箱实现
+func (s *userService) RequestEmailChange(userID uint, email, password string) error {
+	db := config.GetDB()
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return errors.New("user not found")
+	}
+	// 与修改密码一样，当前密码的猜测与登录共用失败计数
+	if err := s.guard.Reserve(user.Username, ""); err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return errors.New("invalid current password")
+	}
+	s.guard.Succeed(user.Username, "")
+	if email == user.Email {
+		return errors.New("email unchanged")
+	}
+
+	var count int64
+	db.Model(&models.User{}).Where("email = ?", email).Count(&count)
+	if count > 0 {
+		return errors.New("email already exists")
+	}
+
+	// 通知原邮箱，账户被盗用时用户可以及时发现
+	err := GetMailer().Send(&Message{
+		To:      user.Email,
+		Subject: "Email change requested",
+		Body: fmt.Sprintf("Hi %s,\n\nA request was made to change the email address of your account to %s. "+
+			"If this wasn't you, please reset your password.\n", user.Username, email),
+	})
+	if err != nil {
+		utils.Error("Failed to send email change notice to user %d: %v", user.ID, err)
+	}
+
+	return s.sendVerification(&user, email)
+}
+
+// isHTTPURL 判断是否为http或https的绝对地址
+func isHTTPURL(value string) bool {
+	u, err := url.Parse(value)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// sendVerification 生成验证令牌并向指定邮箱发送验证邮件
+func (s *userService) sendVerification(user *models.User, email string) error {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return errors.New("failed to generate token")
+	}
+
+	accountConfig := config.GetAccountConfig()
+	now := time.Now()
+	err = config.GetDB().Transaction(func(tx *gorm.DB) error {
+		// 只有最新的验证令牌有效
+		if err := tx.Model(&models.EmailVerificationToken{}).
+			Where("user_id = ? AND used_at IS NULL", user.ID).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.EmailVerificationToken{
+			UserID:    user.ID,
+			Email:     email,
+			TokenHash: utils.HashToken(token),
+			ExpiresAt: now.Add(accountConfig.EmailVerificationTTL),
+		}).Error
+	})
+	if err != nil {
+		return errors.New("failed to create verification token")
+	}
+
+	link := fmt.Sprintf("%s/api/v1/auth/verify?token=%s", accountConfig.BaseURL, url.QueryEscape(token))
+	err = GetMailer().Send(&Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below. It expires in %s.\n\n%s\n",
+			user.Username, accountConfig.EmailVerificationTTL, link),
+	})
+	if err != nil {
+		return errors.New("failed to send email")
+	}
+
+	return nil
+}
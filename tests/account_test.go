@@ -0,0 +1,221 @@
+package tests
+
+import (
+	"archive/zip"
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/services"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createPostAndComments 作者发布一篇文章，作者和评论者各评论一次
+func createPostAndComments(t *testing.T, author, commenter loginResponse) uint {
+	w := doJSON("POST", "/api/v1/posts/", author.Token, models.PostRequest{Title: "My Data", Content: "content"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Post models.Post `json:"post"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	path := "/api/v1/posts/" + strconv.Itoa(int(created.Post.ID)) + "/comments"
+	doJSON("POST", path, author.Token, models.CommentRequest{Content: "author comment"})
+	doJSON("POST", path, commenter.Token, models.CommentRequest{Content: "reader comment"})
+	return created.Post.ID
+}
+
+// TestAccountExport 测试JSON和ZIP格式的个人数据导出
+func TestAccountExport(t *testing.T) {
+	setupTest(t)
+	author := registerAndLogin(t, "exportuser")
+	createPostAndComments(t, author, registerAndLogin(t, "exportother"))
+	// 已删除的文章仍然保存，同样导出
+	removed := createPost(t, author.Token, models.PostRequest{Title: "Removed", Content: "gone"})
+	assert.Equal(t, http.StatusOK, doJSON("DELETE", "/api/v1/posts/"+strconv.Itoa(int(removed.ID)), author.Token, nil).Code)
+
+	w := doJSON("GET", "/api/v1/user/export?format=json", author.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var export services.AccountExport
+	json.Unmarshal(w.Body.Bytes(), &export)
+	assert.Equal(t, "exportuser@example.com", export.Profile.Email)
+	if assert.Len(t, export.Posts, 2) {
+		assert.Nil(t, export.Posts[0].DeletedAt)
+		assert.Equal(t, "Removed", export.Posts[1].Title)
+		assert.NotNil(t, export.Posts[1].DeletedAt)
+	}
+	assert.Len(t, export.Comments, 1)
+
+	w = doJSON("GET", "/api/v1/user/export", author.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if assert.NoError(t, err) {
+		names := make(map[string]string)
+		for _, f := range archive.File {
+			rc, _ := f.Open()
+			data, _ := io.ReadAll(rc)
+			rc.Close()
+			names[f.Name] = string(data)
+		}
+		assert.Contains(t, names["posts.json"], "My Data")
+		assert.Contains(t, names["profile.json"], "exportuser")
+	}
+}
+
+// TestAccountDeletionAnonymize 测试冷静期内撤销注销，以及到期后匿名化
+func TestAccountDeletionAnonymize(t *testing.T) {
+	setupTest(t)
+	author := registerAndLogin(t, "leaver")
+	reader := registerAndLogin(t, "stayer")
+	postID := createPostAndComments(t, author, reader)
+
+	w := doJSON("DELETE", "/api/v1/user", author.Token, models.DeleteAccountRequest{Password: "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doJSON("DELETE", "/api/v1/user", author.Token, models.DeleteAccountRequest{Password: "password123"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	w = doJSON("POST", "/api/v1/user/deletion/cancel", author.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 撤销后不会被删除
+	accountService := services.NewAccountService()
+	purged, _ := accountService.PurgeDueAccounts(time.Now().Add(30 * 24 * time.Hour))
+	assert.Equal(t, 0, purged)
+
+	w = doJSON("DELETE", "/api/v1/user", author.Token, models.DeleteAccountRequest{Password: "password123"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	purged, _ = accountService.PurgeDueAccounts(time.Now())
+	assert.Equal(t, 0, purged)
+	purged, _ = accountService.PurgeDueAccounts(time.Now().Add(8 * 24 * time.Hour))
+	assert.Equal(t, 1, purged)
+
+	w = doJSON("GET", "/api/v1/user/profile", author.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doJSON("GET", "/api/v1/users/leaver", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 文章和评论保留，归属占位用户
+	var post models.Post
+	testDB.Preload("User").First(&post, postID)
+	assert.Equal(t, "deleted", post.User.Username)
+	var comments int64
+	testDB.Model(&models.Comment{}).Where("post_id = ?", postID).Count(&comments)
+	assert.Equal(t, int64(2), comments)
+
+	// 用户名和邮箱可以重新注册，占位用户名不能注册
+	registerAndLogin(t, "leaver")
+	w = doJSON("POST", "/api/v1/auth/register", "", models.RegisterRequest{Username: "deleted", Password: "password123", Email: "d@example.com"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+// TestAccountDeletionHard 测试hard策略连同文章和文章下的评论一起删除
+func TestAccountDeletionHard(t *testing.T) {
+	t.Setenv("ACCOUNT_DELETION_POLICY", "hard")
+	t.Setenv("ACCOUNT_DELETION_GRACE_PERIOD", "0s")
+	setupTest(t)
+	author := registerAndLogin(t, "hardleaver")
+	reader := registerAndLogin(t, "hardstayer")
+	postID := createPostAndComments(t, author, reader)
+
+	// 在他人文章下被回复过的评论保留为占位
+	readerPost := createPost(t, reader.Token, models.PostRequest{Title: "Reader post", Content: "c"})
+	readerPath := "/api/v1/posts/" + strconv.Itoa(int(readerPost.ID)) + "/comments"
+	w := doJSON("POST", readerPath, author.Token, models.CommentRequest{Content: "leaving soon"})
+	var created struct {
+		Comment models.Comment `json:"comment"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	doJSON("POST", readerPath, reader.Token, models.CommentRequest{Content: "reply", ParentID: &created.Comment.ID})
+
+	w = doJSON("DELETE", "/api/v1/user", author.Token, models.DeleteAccountRequest{Password: "password123"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	purged, _ := services.NewAccountService().PurgeDueAccounts(time.Now())
+	assert.Equal(t, 1, purged)
+
+	var tombstone models.Comment
+	assert.NoError(t, testDB.First(&tombstone, created.Comment.ID).Error)
+	assert.True(t, tombstone.Deleted)
+	assert.Equal(t, uint(0), tombstone.UserID)
+	contents, _ := listComments(t, readerPath+"?order=thread", "")
+	assert.Equal(t, []string{models.CommentDeletedContent, "reply"}, contents)
+
+	var count int64
+	testDB.Unscoped().Model(&models.Post{}).Where("id = ?", postID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	testDB.Unscoped().Model(&models.Comment{}).Where("post_id = ?", postID).Count(&count)
+	assert.Equal(t, int64(0), count)
+	testDB.Unscoped().Model(&models.User{}).Where("username = ?", "hardleaver").Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// 评论者的账户不受影响
+	w = doJSON("GET", "/api/v1/user/profile", reader.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestAccountDeletionEmailConfirmation 测试不提交密码时通过邮件确认注销，以及注销时的密码猜测限制
+func TestAccountDeletionEmailConfirmation(t *testing.T) {
+	setupTest(t)
+	login := registerAndLogin(t, "oidcleaver")
+
+	// 不带请求体的DELETE请求
+	w := doJSON("DELETE", "/api/v1/user", login.Token, nil)
+	assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	token := lastMailToken(t, testOutbox)
+	var user models.User
+	testDB.First(&user, login.User.ID)
+	assert.Nil(t, user.DeletionDueAt)
+
+	w = doJSON("POST", "/api/v1/auth/deletion/confirm", "", models.ConfirmDeletionRequest{Token: "bogus"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON("POST", "/api/v1/auth/deletion/confirm", "", models.ConfirmDeletionRequest{Token: token})
+	assert.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	testDB.First(&user, login.User.ID)
+	assert.NotNil(t, user.DeletionDueAt)
+
+	// 令牌只能使用一次
+	w = doJSON("POST", "/api/v1/auth/deletion/confirm", "", models.ConfirmDeletionRequest{Token: token})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 密码方式与登录共用失败计数
+	other := registerAndLogin(t, "guessedleaver")
+	for i := 0; i < config.GetLoginProtectionConfig().UserFreeAttempts; i++ {
+		w = doJSON("DELETE", "/api/v1/user", other.Token, models.DeleteAccountRequest{Password: "guess"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+	w = doJSON("DELETE", "/api/v1/user", other.Token, models.DeleteAccountRequest{Password: "password123"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+// TestAccountDeletionPlaceholderFlag 测试占位用户以标记识别，同名的普通用户不会得到匿名化的内容
+func TestAccountDeletionPlaceholderFlag(t *testing.T) {
+	t.Setenv("ACCOUNT_DELETION_GRACE_PERIOD", "0s")
+	setupTest(t)
+	// 保留用户名之前注册的同名普通用户
+	impostor := models.User{Username: "deleted", Password: "x", Email: "impostor@example.com", Role: models.RoleAuthor}
+	assert.NoError(t, testDB.Create(&impostor).Error)
+
+	author := registerAndLogin(t, "flagleaver")
+	postID := createPostAndComments(t, author, registerAndLogin(t, "flagreader"))
+	w := doJSON("DELETE", "/api/v1/user", author.Token, models.DeleteAccountRequest{Password: "password123"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	purged, _ := services.NewAccountService().PurgeDueAccounts(time.Now())
+	assert.Equal(t, 1, purged)
+
+	var post models.Post
+	testDB.Preload("User").First(&post, postID)
+	assert.NotEqual(t, impostor.ID, post.UserID)
+	assert.True(t, post.User.Placeholder)
+	var owned int64
+	testDB.Model(&models.Post{}).Where("user_id = ?", impostor.ID).Count(&owned)
+	assert.Equal(t, int64(0), owned)
+
+	// 保留的用户名不区分大小写
+	w = doJSON("POST", "/api/v1/auth/register", "", models.RegisterRequest{Username: "Deleted", Password: "password123", Email: "d2@example.com"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
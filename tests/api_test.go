@@ -4,6 +4,7 @@ import (
 	"blog-backend/api"
 	"blog-backend/config"
 	"blog-backend/models"
+	"blog-backend/services"
 	"blog-backend/utils"
 	"bytes"
 	"encoding/json"
@@ -15,7 +16,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -26,6 +26,7 @@ var (
 	testPostID  uint
 	testCommentID uint
 	testToken   string
+	testOutbox  string
 )
 
 // setupTest 设置测试环境
@@ -35,7 +36,7 @@ func setupTest(t *testing.T) {
 
 	// 初始化测试数据库
 	var err error
-	testDB, err = gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	testDB, err = gorm.Open(config.SQLiteDialector(":memory:"), &gorm.Config{})
 	assert.NoError(t, err)
 
 	// 保存原始DB实例并替换为测试DB
@@ -43,11 +44,28 @@ func setupTest(t *testing.T) {
 	config.DB = testDB
 
 	// 自动迁移表结构
-	err = testDB.AutoMigrate(&models.User{}, &models.Post{}, &models.Comment{})
+	err = config.MigrateDB(testDB)
 	assert.NoError(t, err)
 
+	// 邮件写入临时发件箱
+	testOutbox = useOutbox(t)
+
+	// 每个测试使用独立的登录失败记录
+	services.SetLoginAttemptStore(services.NewMemoryAttemptStore())
+
+	// 按当前环境变量重新加载JWT密钥，测试未指定时使用测试专用的密钥
+	if os.Getenv("JWT_SECRET") == "" {
+		t.Setenv("JWT_SECRET", "test-jwt-secret")
+	}
+	if os.Getenv("CURSOR_SECRET") == "" {
+		t.Setenv("CURSOR_SECRET", "test-cursor-secret")
+	}
+	assert.NoError(t, services.ReloadKeyRing())
+	assert.NoError(t, services.CheckCursorSecret())
+
 	// 创建Gin引擎
 	r = gin.Default()
+	assert.NoError(t, api.SetupTrustedProxies(r))
 
 	// 使用api包中的路由配置
 	api.SetupRoutes(r)
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// loginResponse 登录/刷新接口的响应结构
+type loginResponse struct {
+	Message      string `json:"message"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	User         struct {
+		ID       uint   `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+}
+
+// doJSON 发送JSON请求并返回响应记录
+func doJSON(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		data, _ := json.Marshal(body)
+		buf.Write(data)
+	}
+	req, _ := http.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// registerAndLogin 注册并登录一个用户
+func registerAndLogin(t *testing.T, username string) loginResponse {
+	w := doJSON("POST", "/api/v1/auth/register", "", models.RegisterRequest{
+		Username: username,
+		Password: "password123",
+		Email:    username + "@example.com",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	w = doJSON("POST", "/api/v1/auth/login", "", models.LoginRequest{
+		Username: username,
+		Password: "password123",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp loginResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+// TestRefreshToken 测试刷新令牌轮换
+func TestRefreshToken(t *testing.T) {
+	setupTest(t)
+	login := registerAndLogin(t, "refreshuser")
+	assert.NotEmpty(t, login.RefreshToken)
+
+	w := doJSON("POST", "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: login.RefreshToken})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var refreshed loginResponse
+	json.Unmarshal(w.Body.Bytes(), &refreshed)
+	assert.NotEmpty(t, refreshed.Token)
+	assert.NotEqual(t, login.RefreshToken, refreshed.RefreshToken)
+
+	// 新的访问令牌可以正常使用
+	w = doJSON("GET", "/api/v1/user/profile", refreshed.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestRefreshTokenReuse 测试重复使用刷新令牌会吊销整个会话
+func TestRefreshTokenReuse(t *testing.T) {
+	setupTest(t)
+	login := registerAndLogin(t, "reuseuser")
+
+	w := doJSON("POST", "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: login.RefreshToken})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var refreshed loginResponse
+	json.Unmarshal(w.Body.Bytes(), &refreshed)
+
+	// 再次使用旧令牌
+	w = doJSON("POST", "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: login.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 整个令牌族都已失效
+	w = doJSON("POST", "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: refreshed.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doJSON("GET", "/api/v1/user/profile", refreshed.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestLogout 测试注销后访问令牌和刷新令牌均失效
+func TestLogout(t *testing.T) {
+	setupTest(t)
+	login := registerAndLogin(t, "logoutuser")
+
+	w := doJSON("POST", "/api/v1/auth/logout", "", models.RefreshRequest{RefreshToken: login.RefreshToken})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON("GET", "/api/v1/user/profile", login.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doJSON("POST", "/api/v1/auth/refresh", "", models.RefreshRequest{RefreshToken: login.RefreshToken})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createCategory 创建分类并返回结果
+func createCategory(t *testing.T, token string, req models.CategoryRequest) models.Category {
+	w := doJSON("POST", "/api/v1/admin/categories", token, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var resp struct {
+		Category models.Category `json:"category"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp.Category
+}
+
+// TestCategoryTree 测试分类树管理、按分类（含子分类）列出文章和删除分类
+func TestCategoryTree(t *testing.T) {
+	setupTest(t)
+	editor := loginAs(t, "categoryeditor", models.RoleEditor)
+	author := registerAndLogin(t, "categoryauthor")
+
+	// 只有编辑和管理员可以管理分类
+	w := doJSON("POST", "/api/v1/admin/categories", author.Token, models.CategoryRequest{Name: "Tech"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	tech := createCategory(t, editor.Token, models.CategoryRequest{Name: "Tech", Description: "Technology", Position: 1})
+	life := createCategory(t, editor.Token, models.CategoryRequest{Name: "Life"})
+	golang := createCategory(t, editor.Token, models.CategoryRequest{Name: "Go", ParentID: &tech.ID})
+	w = doJSON("POST", "/api/v1/admin/categories", editor.Token, models.CategoryRequest{Name: "Tech"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 不能移动到自己的子分类下
+	w = doJSON("PUT", "/api/v1/admin/categories/"+strconv.Itoa(int(tech.ID)), editor.Token, models.CategoryRequest{Name: "Tech", ParentID: &golang.ID})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON("GET", "/api/v1/categories", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tree struct {
+		Categories []models.Category `json:"categories"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &tree)
+	assert.Len(t, tree.Categories, 2)
+	assert.Equal(t, "life", tree.Categories[0].Slug)
+	assert.Equal(t, "go", tree.Categories[1].Children[0].Slug)
+
+	// 分类下的文章包括子分类的文章
+	zero := uint(0)
+	post := createPost(t, author.Token, models.PostRequest{Title: "Goroutines", Content: "c", CategoryID: &golang.ID})
+	assert.Equal(t, "go", post.Category.Slug)
+	createPost(t, author.Token, models.PostRequest{Title: "Hardware", Content: "c", CategoryID: &tech.ID})
+	missing := uint(999)
+	w = doJSON("POST", "/api/v1/posts/", author.Token, models.PostRequest{Title: "x", Content: "c", CategoryID: &missing})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	assert.Equal(t, int64(2), listPosts(t, "/api/v1/categories/tech/posts", "").Pagination.Total)
+	assert.Equal(t, int64(1), listPosts(t, "/api/v1/posts?category=go", "").Pagination.Total)
+	assert.Equal(t, int64(0), listPosts(t, "/api/v1/categories/life/posts", "").Pagination.Total)
+
+	// 仍有文章的分类不能直接删除，可以把文章转到其它分类
+	goPath := "/api/v1/admin/categories/" + strconv.Itoa(int(golang.ID))
+	assert.Equal(t, http.StatusConflict, doJSON("DELETE", goPath, editor.Token, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, doJSON("DELETE", goPath+"?reassign_to=999", editor.Token, nil).Code)
+	assert.Equal(t, http.StatusOK, doJSON("DELETE", goPath+"?reassign_to="+strconv.Itoa(int(life.ID)), editor.Token, nil).Code)
+	assert.Equal(t, int64(1), listPosts(t, "/api/v1/categories/life/posts", "").Pagination.Total)
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", "/api/v1/categories/go", "", nil).Code)
+
+	// 取消文章的分类后可以直接删除分类，子分类上移一级
+	sub := createCategory(t, editor.Token, models.CategoryRequest{Name: "Family", ParentID: &life.ID})
+	w = doJSON("PUT", "/api/v1/posts/"+strconv.Itoa(int(post.ID)), author.Token, models.PostRequest{Title: "Goroutines", Content: "c", CategoryID: &zero})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusOK, doJSON("DELETE", "/api/v1/admin/categories/"+strconv.Itoa(int(life.ID)), editor.Token, nil).Code)
+	w = doJSON("GET", "/api/v1/categories/"+sub.Slug, "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"parent_id":null`)
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// commentListResponse 评论列表响应
+type commentListResponse struct {
+	Comments   []models.Comment `json:"comments"`
+	Pagination struct {
+		Total      int64  `json:"total"`
+		TotalPages int64  `json:"total_pages"`
+		NextCursor string `json:"next_cursor"`
+	} `json:"pagination"`
+}
+
+// listComments 获取评论列表，返回评论内容
+func listComments(t *testing.T, path, token string) ([]string, commentListResponse) {
+	w := doJSON("GET", path, token, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp commentListResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	contents := make([]string, len(resp.Comments))
+	for i, comment := range resp.Comments {
+		contents[i] = comment.Content
+	}
+	return contents, resp
+}
+
+// TestCommentListing 测试评论列表的分页、排序和草稿可见性
+func TestCommentListing(t *testing.T) {
+	setupTest(t)
+	author := registerAndLogin(t, "talker")
+	post := createPost(t, author.Token, models.PostRequest{Title: "Chatty", Content: "c"})
+	path := "/api/v1/posts/" + strconv.Itoa(int(post.ID)) + "/comments"
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusCreated, doJSON("POST", path, author.Token, models.CommentRequest{Content: "comment " + strconv.Itoa(i)}).Code)
+	}
+
+	contents, resp := listComments(t, path+"?page_size=2", "")
+	assert.Equal(t, []string{"comment 4", "comment 3"}, contents)
+	assert.Equal(t, int64(5), resp.Pagination.Total)
+	assert.Equal(t, int64(3), resp.Pagination.TotalPages)
+
+	contents, _ = listComments(t, path+"?page_size=2&page=3&order=oldest", "")
+	assert.Equal(t, []string{"comment 4"}, contents)
+
+	// 正序的游标分页
+	contents, resp = listComments(t, path+"?limit=3&order=oldest", "")
+	assert.Equal(t, []string{"comment 0", "comment 1", "comment 2"}, contents)
+	next := resp.Pagination.NextCursor
+	contents, _ = listComments(t, path+"?limit=3&order=oldest&cursor="+url.QueryEscape(next), "")
+	assert.Equal(t, []string{"comment 3", "comment 4"}, contents)
+	// 正序列表的游标不能用于倒序列表
+	assert.Equal(t, http.StatusBadRequest, doJSON("GET", path+"?limit=3&cursor="+url.QueryEscape(next), "", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, doJSON("GET", path+"?order=random", "", nil).Code)
+
+	// 草稿下的评论只有作者可以查看
+	draft := createPost(t, author.Token, models.PostRequest{Title: "Hidden", Content: "c", Status: models.PostStatusDraft})
+	draftPath := "/api/v1/posts/" + strconv.Itoa(int(draft.ID)) + "/comments"
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", draftPath, "", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", draftPath+"?limit=5", "", nil).Code)
+	_, resp = listComments(t, draftPath, author.Token)
+	assert.Equal(t, int64(0), resp.Pagination.Total)
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", "/api/v1/posts/9999/comments", "", nil).Code)
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCommentThreads 测试评论回复的层级、树形和平铺列表以及删除后的占位
+func TestCommentThreads(t *testing.T) {
+	setupTest(t)
+	t.Setenv("COMMENT_MAX_DEPTH", "2")
+	author := registerAndLogin(t, "threader")
+	post := createPost(t, author.Token, models.PostRequest{Title: "Threads", Content: "c"})
+	other := createPost(t, author.Token, models.PostRequest{Title: "Elsewhere", Content: "c"})
+	path := "/api/v1/posts/" + strconv.Itoa(int(post.ID)) + "/comments"
+
+	reply := func(content string, parentID *uint) models.Comment {
+		w := doJSON("POST", path, author.Token, models.CommentRequest{Content: content, ParentID: parentID})
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var resp struct {
+			Comment models.Comment `json:"comment"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Comment
+	}
+	root := reply("root", nil)
+	child := reply("child", &root.ID)
+	grandchild := reply("grandchild", &child.ID)
+	second := reply("second root", nil)
+	assert.Equal(t, 2, grandchild.Depth)
+	assert.Equal(t, fmt.Sprintf("%010d/%010d", root.ID, child.ID), child.Path)
+	assert.Equal(t, child.Path+fmt.Sprintf("/%010d", grandchild.ID), grandchild.Path)
+
+	// 超过最大层级和回复其它文章的评论都被拒绝
+	assert.Equal(t, http.StatusBadRequest, doJSON("POST", path, author.Token, models.CommentRequest{Content: "too deep", ParentID: &grandchild.ID}).Code)
+	otherPath := "/api/v1/posts/" + strconv.Itoa(int(other.ID)) + "/comments"
+	assert.Equal(t, http.StatusNotFound, doJSON("POST", otherPath, author.Token, models.CommentRequest{Content: "x", ParentID: &root.ID}).Code)
+
+	// 树形列表按根评论分页，回复嵌套在上级评论中
+	var tree struct {
+		Comments   []models.Comment `json:"comments"`
+		Pagination struct {
+			Total int64 `json:"total"`
+		} `json:"pagination"`
+	}
+	w := doJSON("GET", path+"?view=tree&order=oldest", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &tree)
+	assert.Equal(t, int64(2), tree.Pagination.Total)
+	assert.Equal(t, root.ID, tree.Comments[0].ID)
+	assert.Equal(t, int64(1), tree.Comments[0].ReplyCount)
+	assert.Equal(t, grandchild.ID, tree.Comments[0].Replies[0].Replies[0].ID)
+	assert.Equal(t, second.ID, tree.Comments[1].ID)
+	assert.Empty(t, tree.Comments[1].Replies)
+
+	// 讨论串顺序的平铺列表
+	contents, _ := listComments(t, path+"?order=thread", "")
+	assert.Equal(t, []string{"root", "child", "grandchild", "second root"}, contents)
+	assert.Equal(t, http.StatusBadRequest, doJSON("GET", path+"?order=thread&limit=2", "", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, doJSON("GET", path+"?view=graph", "", nil).Code)
+
+	// 删除有回复的评论保留为占位，回复不受影响
+	commentPath := "/api/v1/comments/" + strconv.Itoa(int(child.ID))
+	assert.Equal(t, http.StatusOK, doJSON("DELETE", commentPath, author.Token, nil).Code)
+	var tombstone models.Comment
+	testDB.First(&tombstone, child.ID)
+	assert.True(t, tombstone.Deleted)
+	assert.Equal(t, models.CommentDeletedContent, tombstone.Content)
+	contents, _ = listComments(t, path+"?order=thread", "")
+	assert.Equal(t, []string{"root", "[deleted]", "grandchild", "second root"}, contents)
+	assert.Equal(t, http.StatusNotFound, doJSON("PUT", commentPath, author.Token, models.CommentRequest{Content: "back"}).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON("POST", path, author.Token, models.CommentRequest{Content: "x", ParentID: &child.ID}).Code)
+	assert.Equal(t, int64(3), listPosts(t, "/api/v1/posts?author=threader&sort=comments", "").Posts[0].CommentCount)
+
+	// 文章详情只返回评论数，不嵌入评论
+	w = doJSON("GET", "/api/v1/posts/"+strconv.Itoa(int(post.ID)), "", nil)
+	var detail map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &detail)
+	assert.Equal(t, float64(3), detail["comment_count"])
+	assert.NotContains(t, detail, "comments")
+
+	// 删除最后一条回复后，没有其它回复的占位评论一并删除
+	assert.Equal(t, http.StatusOK, doJSON("DELETE", "/api/v1/comments/"+strconv.Itoa(int(grandchild.ID)), author.Token, nil).Code)
+	contents, _ = listComments(t, path+"?order=thread", "")
+	assert.Equal(t, []string{"root", "second root"}, contents)
+}
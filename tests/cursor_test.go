@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cursorPostList 游标分页的文章列表响应
+type cursorPostList struct {
+	Posts      []models.Post `json:"posts"`
+	Pagination struct {
+		Limit      int    `json:"limit"`
+		NextCursor string `json:"next_cursor"`
+		PrevCursor string `json:"prev_cursor"`
+	} `json:"pagination"`
+}
+
+// cursorPosts 使用游标分页获取文章列表，返回文章ID
+func cursorPosts(t *testing.T, path string) ([]uint, cursorPostList) {
+	w := doJSON("GET", path, "", nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp cursorPostList
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	ids := make([]uint, len(resp.Posts))
+	for i, post := range resp.Posts {
+		ids[i] = post.ID
+	}
+	return ids, resp
+}
+
+// TestPostCursorPagination 测试文章列表的游标分页
+func TestPostCursorPagination(t *testing.T) {
+	setupTest(t)
+	author := registerAndLogin(t, "pager")
+
+	var ids []uint
+	for i := 0; i < 5; i++ {
+		ids = append(ids, createPost(t, author.Token, models.PostRequest{Title: "Post " + strconv.Itoa(i), Content: "c"}).ID)
+	}
+	// 中间三篇文章的创建时间相同且最早，相同时按ID倒序，第二页和第三页的分界落在相同时间的文章之间
+	testDB.Model(&models.Post{}).Where("id IN ?", ids[1:4]).Update("created_at", time.Now().Add(-time.Hour))
+
+	page1, resp := cursorPosts(t, "/api/v1/posts?limit=2")
+	assert.Equal(t, []uint{ids[4], ids[0]}, page1)
+	assert.Empty(t, resp.Pagination.PrevCursor)
+
+	// 翻页过程中发布的新文章不会导致重复或遗漏
+	createPost(t, author.Token, models.PostRequest{Title: "Late", Content: "c"})
+
+	page2, resp := cursorPosts(t, "/api/v1/posts?limit=2&cursor="+url.QueryEscape(resp.Pagination.NextCursor))
+	assert.Equal(t, []uint{ids[3], ids[2]}, page2)
+	prev := resp.Pagination.PrevCursor
+
+	page3, resp := cursorPosts(t, "/api/v1/posts?limit=2&cursor="+url.QueryEscape(resp.Pagination.NextCursor))
+	assert.Equal(t, []uint{ids[1]}, page3)
+	assert.Empty(t, resp.Pagination.NextCursor)
+
+	back, _ := cursorPosts(t, "/api/v1/posts?limit=2&cursor="+url.QueryEscape(prev))
+	assert.Equal(t, []uint{ids[4], ids[0]}, back)
+
+	// 作者的文章列表同样支持游标分页
+	authored, _ := cursorPosts(t, "/api/v1/users/pager/posts?limit=10")
+	assert.Len(t, authored, 6)
+
+	// 篡改过的游标被拒绝
+	tampered := []byte(resp.Pagination.PrevCursor)
+	tampered[0] ^= 1
+	assert.Equal(t, http.StatusBadRequest, doJSON("GET", "/api/v1/posts?cursor="+url.QueryEscape(string(tampered)), "", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, doJSON("GET", "/api/v1/posts?cursor=garbage", "", nil).Code)
+}
+
+// TestCommentCursorPagination 测试评论列表的游标分页
+func TestCommentCursorPagination(t *testing.T) {
+	setupTest(t)
+	author := registerAndLogin(t, "commenter")
+	post := createPost(t, author.Token, models.PostRequest{Title: "Discussed", Content: "c"})
+	path := "/api/v1/posts/" + strconv.Itoa(int(post.ID)) + "/comments"
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusCreated, doJSON("POST", path, author.Token, models.CommentRequest{Content: "comment " + strconv.Itoa(i)}).Code)
+	}
+
+	var resp struct {
+		Comments   []models.Comment `json:"comments"`
+		Pagination struct {
+			NextCursor string `json:"next_cursor"`
+		} `json:"pagination"`
+	}
+	w := doJSON("GET", path+"?limit=2", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Len(t, resp.Comments, 2)
+	assert.Equal(t, "comment 2", resp.Comments[0].Content)
+	next := resp.Pagination.NextCursor
+
+	w = doJSON("GET", path+"?limit=2&cursor="+url.QueryEscape(next), "", nil)
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Len(t, resp.Comments, 1)
+	assert.Equal(t, "comment 0", resp.Comments[0].Content)
+
+	// 评论的游标不能用于文章列表
+	assert.Equal(t, http.StatusBadRequest, doJSON("GET", "/api/v1/posts?cursor="+url.QueryEscape(next), "", nil).Code)
+}
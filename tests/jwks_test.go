@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"blog-backend/config"
+	"blog-backend/services"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeKey 将私钥写入密钥目录
+func writeKey(t *testing.T, dir, kid string, block *pem.Block) {
+	err := os.WriteFile(filepath.Join(dir, kid+".pem"), pem.EncodeToMemory(block), 0600)
+	assert.NoError(t, err)
+}
+
+// TestAsymmetricSigningRotation 测试非对称签名、密钥轮换和JWKS公钥
+func TestAsymmetricSigningRotation(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	writeKey(t, dir, "2026-01", &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(edKey)
+	assert.NoError(t, err)
+	writeKey(t, dir, "2026-02", &pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	t.Setenv("JWT_KEYS_DIR", dir)
+	t.Setenv("JWT_ACTIVE_KID", "2026-01")
+	setupTest(t)
+
+	login := registerAndLogin(t, "jwksuser")
+	token, _, err := jwt.NewParser().ParseUnverified(login.Token, jwt.MapClaims{})
+	assert.NoError(t, err)
+	assert.Equal(t, "RS256", token.Header["alg"])
+	assert.Equal(t, "2026-01", token.Header["kid"])
+
+	// 其它服务可以使用JWKS中的公钥自行验证令牌
+	w := doJSON("GET", "/.well-known/jwks.json", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var jwks struct {
+		Keys []services.JWK `json:"keys"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &jwks)
+	if assert.Len(t, jwks.Keys, 2) {
+		assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+		assert.Equal(t, "OKP", jwks.Keys[1].Kty)
+		n, _ := base64.RawURLEncoding.DecodeString(jwks.Keys[0].N)
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+		_, err = jwt.Parse(login.Token, func(*jwt.Token) (interface{}, error) { return pub, nil })
+		assert.NoError(t, err)
+	}
+	assert.NotContains(t, w.Body.String(), `"d"`)
+
+	// 轮换到新密钥后，旧密钥签发的令牌仍然有效
+	t.Setenv("JWT_ACTIVE_KID", "2026-02")
+	assert.NoError(t, services.ReloadKeyRing())
+	w = doJSON("GET", "/api/v1/user/profile", login.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	relogin := registerAndLogin(t, "jwksuser2")
+	token, _, _ = jwt.NewParser().ParseUnverified(relogin.Token, jwt.MapClaims{})
+	assert.Equal(t, "EdDSA", token.Header["alg"])
+	assert.Equal(t, "2026-02", token.Header["kid"])
+
+	// 不再接受HMAC签名的令牌
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": 1}).SignedString([]byte("your_secret_key_change_in_production"))
+	w = doJSON("GET", "/api/v1/user/profile", forged, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestSigningSecretsRequired 测试未配置密钥或使用公开的默认密钥时拒绝启动
+func TestSigningSecretsRequired(t *testing.T) {
+	_, err := services.LoadKeyRing(config.JWTConfig{})
+	assert.Error(t, err)
+	_, err = services.LoadKeyRing(config.JWTConfig{SecretKey: config.InsecureJWTSecret})
+	assert.Error(t, err)
+	_, err = services.LoadKeyRing(config.JWTConfig{SecretKey: "a-real-secret"})
+	assert.NoError(t, err)
+
+	// 游标密钥必须单独配置
+	t.Setenv("JWT_SECRET", "a-real-secret")
+	t.Setenv("CURSOR_SECRET", "")
+	assert.Error(t, services.CheckCursorSecret())
+	t.Setenv("CURSOR_SECRET", "a-real-secret")
+	assert.Error(t, services.CheckCursorSecret())
+	t.Setenv("CURSOR_SECRET", "another-secret")
+	assert.NoError(t, services.CheckCursorSecret())
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/services"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoginLockout 测试连续登录失败后返回429以及管理员解锁
+func TestLoginLockout(t *testing.T) {
+	setupTest(t)
+	admin := loginAs(t, "lockadmin", models.RoleAdmin)
+	victim := registerAndLogin(t, "lockvictim")
+
+	wrong := models.LoginRequest{Username: "lockvictim", Password: "wrongpassword"}
+	for i := 0; i < 3; i++ {
+		w := doJSON("POST", "/api/v1/auth/login", "", wrong)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	// 受限期间即使密码正确也被拒绝
+	w := doJSON("POST", "/api/v1/auth/login", "", models.LoginRequest{Username: "lockvictim", Password: "password123"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// 普通用户不能解锁
+	w = doJSON("POST", "/api/v1/admin/users/"+strconv.Itoa(int(victim.User.ID))+"/unlock", victim.Token, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doJSON("POST", "/api/v1/admin/users/"+strconv.Itoa(int(victim.User.ID))+"/unlock", admin.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON("POST", "/api/v1/auth/login", "", models.LoginRequest{Username: "lockvictim", Password: "password123"})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestDBAttemptStore 测试数据库存储的预先记录、受限时不记录、撤销、过期重置和清除
+func TestDBAttemptStore(t *testing.T) {
+	setupTest(t)
+	store := services.NewDBAttemptStore()
+	now := time.Now()
+	never := func(services.AttemptState) time.Time { return time.Time{} }
+
+	until, err := store.Reserve("user:dbstore", now, time.Hour, never)
+	assert.NoError(t, err)
+	assert.True(t, until.IsZero())
+	_, err = store.Reserve("user:dbstore", now.Add(time.Minute), time.Hour, never)
+	assert.NoError(t, err)
+	state, _ := store.Get("user:dbstore")
+	assert.Equal(t, 2, state.Failures)
+
+	// 受限时返回可以再次尝试的时间，不增加计数
+	blocked := now.Add(time.Hour)
+	until, _ = store.Reserve("user:dbstore", now.Add(2*time.Minute), time.Hour, func(services.AttemptState) time.Time { return blocked })
+	assert.True(t, until.Equal(blocked))
+	state, _ = store.Get("user:dbstore")
+	assert.Equal(t, 2, state.Failures)
+
+	assert.NoError(t, store.Release("user:dbstore"))
+	state, _ = store.Get("user:dbstore")
+	assert.Equal(t, 1, state.Failures)
+
+	// 超过统计窗口后重新计数
+	store.Reserve("user:dbstore", now.Add(2*time.Hour), time.Hour, never)
+	state, _ = store.Get("user:dbstore")
+	assert.Equal(t, 1, state.Failures)
+
+	assert.NoError(t, store.Reset("user:dbstore"))
+	state, _ = store.Get("user:dbstore")
+	assert.Equal(t, 0, state.Failures)
+}
+
+// TestLoginGuardConcurrentReserve 测试并发的猜测不能同时通过检查，只有免等待次数内的尝试被放行
+func TestLoginGuardConcurrentReserve(t *testing.T) {
+	services.SetLoginAttemptStore(services.NewMemoryAttemptStore())
+	guard := services.NewLoginGuard()
+
+	var wg sync.WaitGroup
+	var allowed atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if guard.Reserve("burst", "") == nil {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(config.GetLoginProtectionConfig().UserFreeAttempts), allowed.Load())
+
+	// 成功的尝试撤销预先记录的失败，同一IP上的正常登录不会累积
+	for i := 0; i < 2*config.GetLoginProtectionConfig().IPFreeAttempts; i++ {
+		assert.NoError(t, guard.Reserve("office", "192.0.2.10"))
+		guard.Succeed("office", "192.0.2.10")
+	}
+}
+
+// TestLoginIPLimitIgnoresForwardedFor 测试未配置可信代理时伪造 X-Forwarded-For 不能重置IP的失败计数
+func TestLoginIPLimitIgnoresForwardedFor(t *testing.T) {
+	setupTest(t)
+	login := func(i int) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/api/v1/auth/login", strings.NewReader(
+			`{"username":"guess`+strconv.Itoa(i)+`","password":"wrongpassword"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", "198.51.100."+strconv.Itoa(i))
+		req.RemoteAddr = "203.0.113.7:40000"
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 每次使用不同的用户名，只有IP的计数会累积
+	free := config.GetLoginProtectionConfig().IPFreeAttempts
+	for i := 0; i < free; i++ {
+		assert.Equal(t, http.StatusUnauthorized, login(i))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, login(free))
+}
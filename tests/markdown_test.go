@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRenderMarkdown 测试Markdown渲染和HTML过滤
+func TestRenderMarkdown(t *testing.T) {
+	cases := []struct {
+		source string
+		want   string
+	}{
+		{"# Title", "<h1>Title</h1>\n"},
+		{"Hello *world* and **bold** ~~gone~~", "<p>Hello <em>world</em> and <strong>bold</strong> <del>gone</del></p>\n"},
+		{"```go\nfmt.Println(\"<hi>\")\n```", "<pre><code class=\"language-go\">fmt.Println(&#34;&lt;hi&gt;&#34;)\n</code></pre>\n"},
+		{"- a\n- b", "<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n"},
+		{"| a | b |\n|:--|--:|\n| 1 | 2 |", "<table>\n<thead>\n<tr>\n<th align=\"left\">a</th>\n<th align=\"right\">b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td align=\"left\">1</td>\n<td align=\"right\">2</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"[link](https://example.com)", "<p><a href=\"https://example.com\" rel=\"nofollow noopener noreferrer\">link</a></p>\n"},
+		{"[a](<b c>)", "<p><a href=\"b%20c\" rel=\"nofollow noopener noreferrer\">a</a></p>\n"},
+		{"- [x] done", "<ul>\n<li><input checked=\"\" disabled=\"\" type=\"checkbox\" /> done</li>\n</ul>\n"},
+		{"see https://example.com", "<p>see <a href=\"https://example.com\" rel=\"nofollow noopener noreferrer\">https://example.com</a></p>\n"},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, utils.RenderMarkdown(tc.source), tc.source)
+	}
+
+	// 脚本、事件属性和危险协议都会被去掉
+	html := utils.RenderMarkdown("<script>alert(1)</script>\n\n<div onclick=\"alert(1)\">hi</div>\n\n[x](javascript:alert(1)) <img src=x onerror=alert(1)>")
+	assert.NotContains(t, html, "<script")
+	assert.NotContains(t, html, "onclick")
+	assert.NotContains(t, html, "onerror")
+	assert.NotContains(t, html, "javascript:")
+	assert.Contains(t, html, "hi")
+
+	// 评论不允许标题、图片和表格
+	comment := utils.RenderCommentMarkdown("# Big\n\n![img](https://example.com/a.png) **ok**")
+	assert.Equal(t, "<p>Big</p>\n<p> <strong>ok</strong></p>\n", comment)
+	// 只有图片的段落被整个去掉
+	comment = utils.RenderCommentMarkdown("before\n\n![img](https://example.com/a.png)\n\nafter")
+	assert.Equal(t, "<p>before</p>\n<p>after</p>\n", comment)
+}
+
+// TestContentFormat 测试文章和评论的HTML缓存及 format 参数
+func TestContentFormat(t *testing.T) {
+	setupTest(t)
+	user := registerAndLogin(t, "markdowner")
+
+	post := createPost(t, user.Token, models.PostRequest{Title: "Rendered", Content: "Some *markdown*"})
+	assert.Equal(t, "<p>Some <em>markdown</em></p>\n", post.ContentHTML)
+	path := "/api/v1/posts/" + strconv.Itoa(int(post.ID))
+
+	var raw map[string]interface{}
+	w := doJSON("GET", path+"?format=html", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &raw)
+	assert.NotContains(t, raw, "content")
+	assert.Equal(t, "<p>Some <em>markdown</em></p>\n", raw["content_html"])
+
+	raw = nil
+	w = doJSON("GET", path+"?format=markdown", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	json.Unmarshal(w.Body.Bytes(), &raw)
+	assert.NotContains(t, raw, "content_html")
+	assert.Equal(t, "Some *markdown*", raw["content"])
+
+	w = doJSON("GET", path+"?format=pdf", "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 更新正文后重新渲染
+	w = doJSON("PUT", path, user.Token, models.PostRequest{Title: "Rendered", Content: "## Updated"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	list := listPosts(t, "/api/v1/posts?format=html", "")
+	assert.Equal(t, "<h2>Updated</h2>\n", list.Posts[0].ContentHTML)
+	assert.Empty(t, list.Posts[0].Content)
+
+	// 评论使用受限的规则
+	w = doJSON("POST", path+"/comments", user.Token, models.CommentRequest{Content: "# Hi <script>x</script>"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Comment models.Comment `json:"comment"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	assert.Equal(t, "<p>Hi </p>\n", created.Comment.ContentHTML)
+}
+
+// TestRenderStaleContent 测试启动迁移时重新渲染旧版本的HTML缓存
+func TestRenderStaleContent(t *testing.T) {
+	setupTest(t)
+	user := registerAndLogin(t, "stale")
+	post := createPost(t, user.Token, models.PostRequest{Title: "Old", Content: "**old**"})
+	testDB.Model(&models.Post{}).Where("id = ?", post.ID).UpdateColumns(map[string]interface{}{"content_html": "", "render_version": 0})
+
+	assert.NoError(t, config.MigrateDB(testDB))
+
+	var reloaded models.Post
+	testDB.First(&reloaded, post.ID)
+	assert.Equal(t, "<p><strong>old</strong></p>\n", reloaded.ContentHTML)
+	assert.Equal(t, utils.MarkdownRenderVersion, reloaded.RenderVersion)
+}
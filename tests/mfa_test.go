@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// totpCode 计算指定时间步的验证码
+func totpCode(t *testing.T, secret string, step int64) string {
+	code, err := utils.TOTPCode(secret, step)
+	assert.NoError(t, err)
+	return code
+}
+
+// TestTOTPLogin 测试启用两步验证后的两步登录、恢复码和关闭流程
+func TestTOTPLogin(t *testing.T) {
+	setupTest(t)
+	login := registerAndLogin(t, "mfauser")
+
+	// 避免测试过程中跨越时间步边界
+	if time.Now().Unix()%30 > 25 {
+		time.Sleep(6 * time.Second)
+	}
+	step := utils.TOTPStep(time.Now())
+
+	w := doJSON("POST", "/api/v1/user/2fa/setup", login.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var setup struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioning_uri"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &setup)
+	assert.Contains(t, setup.ProvisioningURI, "otpauth://totp/")
+
+	w = doJSON("POST", "/api/v1/user/2fa/enable", login.Token, models.TOTPCodeRequest{Code: totpCode(t, setup.Secret, step-1)})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var enabled struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &enabled)
+	assert.Len(t, enabled.RecoveryCodes, 10)
+
+	// 密码正确时只返回挑战令牌
+	w = doJSON("POST", "/api/v1/auth/login", "", models.LoginRequest{Username: "mfauser", Password: "password123"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var challenge struct {
+		Token       string `json:"token"`
+		MFARequired bool   `json:"mfa_required"`
+		MFAToken    string `json:"mfa_token"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &challenge)
+	assert.True(t, challenge.MFARequired)
+	assert.Empty(t, challenge.Token)
+
+	// 挑战令牌不能作为访问令牌使用
+	w = doJSON("GET", "/api/v1/user/profile", challenge.MFAToken, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doJSON("POST", "/api/v1/auth/login/mfa", "", models.MFALoginRequest{MFAToken: challenge.MFAToken, Code: "000000"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doJSON("POST", "/api/v1/auth/login/mfa", "", models.MFALoginRequest{MFAToken: challenge.MFAToken, Code: totpCode(t, setup.Secret, step)})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var completed loginResponse
+	json.Unmarshal(w.Body.Bytes(), &completed)
+	assert.NotEmpty(t, completed.Token)
+
+	// 恢复码只能使用一次
+	w = doJSON("POST", "/api/v1/auth/login/mfa", "", models.MFALoginRequest{MFAToken: challenge.MFAToken, Code: enabled.RecoveryCodes[0]})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON("POST", "/api/v1/auth/login/mfa", "", models.MFALoginRequest{MFAToken: challenge.MFAToken, Code: enabled.RecoveryCodes[0]})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// 关闭两步验证需要新的验证码，已使用过的验证码无效
+	w = doJSON("POST", "/api/v1/user/2fa/disable", completed.Token, models.TOTPCodeRequest{Code: totpCode(t, setup.Secret, step)})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON("POST", "/api/v1/user/2fa/disable", completed.Token, models.TOTPCodeRequest{Code: totpCode(t, setup.Secret, step+1)})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON("POST", "/api/v1/auth/login", "", models.LoginRequest{Username: "mfauser", Password: "password123"})
+	var relogin loginResponse
+	json.Unmarshal(w.Body.Bytes(), &relogin)
+	assert.NotEmpty(t, relogin.Token)
+}
+
+// TestTOTPManagementThrottled 测试管理两步验证时验证码的猜测与登录共用失败计数
+func TestTOTPManagementThrottled(t *testing.T) {
+	setupTest(t)
+	login := registerAndLogin(t, "mfaguesser")
+
+	w := doJSON("POST", "/api/v1/user/2fa/setup", login.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var setup struct {
+		Secret string `json:"secret"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &setup)
+
+	for i := 0; i < config.GetLoginProtectionConfig().UserFreeAttempts; i++ {
+		w = doJSON("POST", "/api/v1/user/2fa/enable", login.Token, models.TOTPCodeRequest{Code: "000000"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	}
+	// 达到限制后正确的验证码也会被拒绝
+	code := totpCode(t, setup.Secret, utils.TOTPStep(time.Now()))
+	w = doJSON("POST", "/api/v1/user/2fa/enable", login.Token, models.TOTPCodeRequest{Code: code})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+// TestTOTPCode 使用RFC 6238附录B的测试向量校验验证码算法
+func TestTOTPCode(t *testing.T) {
+	// ASCII "12345678901234567890" 的Base32编码
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	code, err := utils.TOTPCode(secret, 59/30)
+	assert.NoError(t, err)
+	assert.Equal(t, "287082", code)
+
+	code, err = utils.TOTPCode(secret, 1111111109/30)
+	assert.NoError(t, err)
+	assert.Equal(t, "081804", code)
+}
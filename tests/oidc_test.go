@@ -0,0 +1,261 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"blog-backend/utils"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockOIDCProvider 本地模拟的OpenID Connect提供方
+type mockOIDCProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	issuer string // 声明的发行方，默认为服务地址
+
+	mu            sync.Mutex
+	subject       string
+	email         string
+	emailVerified interface{}           // ID令牌中的email_verified声明
+	codes         map[string]url.Values // 授权码 -> 授权请求参数
+}
+
+// newMockOIDCProvider 启动模拟提供方，并通过环境变量配置为 mock 提供方
+func newMockOIDCProvider(t *testing.T) *mockOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	p := &mockOIDCProvider{key: key, emailVerified: true, codes: make(map[string]url.Values)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.issuer,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "mock-key",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		clientID, secret, _ := r.BasicAuth()
+		p.mu.Lock()
+		auth, ok := p.codes[r.PostForm.Get("code")]
+		delete(p.codes, r.PostForm.Get("code"))
+		p.mu.Unlock()
+
+		// 校验客户端凭据和PKCE
+		if !ok || clientID != "blog-client" || secret != "blog-secret" ||
+			utils.PKCEChallenge(r.PostForm.Get("code_verifier")) != auth.Get("code_challenge") ||
+			r.PostForm.Get("redirect_uri") != auth.Get("redirect_uri") {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":                p.issuer,
+			"aud":                "blog-client",
+			"sub":                p.subject,
+			"email":              p.email,
+			"email_verified":     p.emailVerified,
+			"preferred_username": "oidc user",
+			"nonce":              auth.Get("nonce"),
+			"iat":                time.Now().Unix(),
+			"exp":                time.Now().Add(time.Minute).Unix(),
+		})
+		token.Header["kid"] = "mock-key"
+		idToken, _ := token.SignedString(key)
+		json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "mock-access-token",
+			"token_type":   "Bearer",
+			"id_token":     idToken,
+		})
+	})
+	p.server = httptest.NewServer(mux)
+	p.issuer = p.server.URL
+	t.Cleanup(p.server.Close)
+
+	t.Setenv("OIDC_PROVIDERS", "mock")
+	t.Setenv("OIDC_MOCK_ISSUER", p.server.URL)
+	t.Setenv("OIDC_MOCK_CLIENT_ID", "blog-client")
+	t.Setenv("OIDC_MOCK_CLIENT_SECRET", "blog-secret")
+	return p
+}
+
+// authorize 模拟用户在提供方处同意授权，返回回调地址的查询参数
+func (p *mockOIDCProvider) authorize(t *testing.T, authURL, subject, email string) (code, state string) {
+	u, err := url.Parse(authURL)
+	assert.NoError(t, err)
+	query := u.Query()
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subject, p.email = subject, email
+	code = "code-" + query.Get("state")[:8]
+	p.codes[code] = query
+	return code, query.Get("state")
+}
+
+// oidcCallback 携带发起授权时下发的Cookie访问回调地址，start为发起授权的响应
+func oidcCallback(start *httptest.ResponseRecorder, code, state string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/api/v1/auth/oidc/mock/callback?code="+code+"&state="+state, nil)
+	for _, cookie := range start.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestOIDCLogin 测试通过模拟提供方的授权码+PKCE登录、再次登录和绑定身份
+func TestOIDCLogin(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	setupTest(t)
+
+	start := doJSON("GET", "/api/v1/auth/oidc/mock/login", "", nil)
+	assert.Equal(t, http.StatusFound, start.Code)
+	code, state := provider.authorize(t, start.Header().Get("Location"), "subject-1", "oidc@example.com")
+
+	w := oidcCallback(start, code, state)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var login loginResponse
+	json.Unmarshal(w.Body.Bytes(), &login)
+	assert.NotEmpty(t, login.Token)
+	assert.Equal(t, "oidcuser", login.User.Username)
+
+	// 授权状态只能使用一次
+	w = oidcCallback(start, code, state)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 同一外部身份再次登录得到同一个用户
+	start = doJSON("GET", "/api/v1/auth/oidc/mock/login", "", nil)
+	code, state = provider.authorize(t, start.Header().Get("Location"), "subject-1", "oidc@example.com")
+	w = oidcCallback(start, code, state)
+	var again loginResponse
+	json.Unmarshal(w.Body.Bytes(), &again)
+	assert.Equal(t, login.User.ID, again.User.ID)
+
+	// 邮箱已被本地账户使用时不自动合并
+	local := registerAndLogin(t, "localuser")
+	start = doJSON("GET", "/api/v1/auth/oidc/mock/login", "", nil)
+	code, state = provider.authorize(t, start.Header().Get("Location"), "subject-2", "localuser@example.com")
+	w = oidcCallback(start, code, state)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 本地用户登录后主动绑定，之后可以通过外部身份登录
+	start = doJSON("POST", "/api/v1/user/identities/mock", local.Token, nil)
+	assert.Equal(t, http.StatusOK, start.Code)
+	var link struct {
+		AuthorizationURL string `json:"authorization_url"`
+	}
+	json.Unmarshal(start.Body.Bytes(), &link)
+	code, state = provider.authorize(t, link.AuthorizationURL, "subject-2", "localuser@example.com")
+	w = oidcCallback(start, code, state)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	start = doJSON("GET", "/api/v1/auth/oidc/mock/login", "", nil)
+	code, state = provider.authorize(t, start.Header().Get("Location"), "subject-2", "localuser@example.com")
+	w = oidcCallback(start, code, state)
+	var linked loginResponse
+	json.Unmarshal(w.Body.Bytes(), &linked)
+	assert.Equal(t, local.User.ID, linked.User.ID)
+
+	w = doJSON("GET", "/api/v1/user/identities", local.Token, nil)
+	assert.Contains(t, w.Body.String(), `"provider":"mock"`)
+}
+
+// TestOIDCStateBoundToBrowser 测试回调必须来自发起授权的浏览器，攻击者无法把自己的绑定请求注入受害者的浏览器
+func TestOIDCStateBoundToBrowser(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	setupTest(t)
+
+	// 攻击者发起绑定并在提供方处使用自己的身份授权，再诱导受害者访问回调地址
+	attacker := registerAndLogin(t, "attacker")
+	start := doJSON("POST", "/api/v1/user/identities/mock", attacker.Token, nil)
+	var link struct {
+		AuthorizationURL string `json:"authorization_url"`
+	}
+	json.Unmarshal(start.Body.Bytes(), &link)
+	code, state := provider.authorize(t, link.AuthorizationURL, "subject-5", "victim@example.com")
+
+	w := doJSON("GET", "/api/v1/auth/oidc/mock/callback?code="+code+"&state="+state, "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 另一个浏览器的Cookie同样无效
+	other := doJSON("GET", "/api/v1/auth/oidc/mock/login", "", nil)
+	w = oidcCallback(other, code, state)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON("GET", "/api/v1/user/identities", attacker.Token, nil)
+	assert.NotContains(t, w.Body.String(), `"provider":"mock"`)
+}
+
+// TestOIDCEmailVerifiedString 测试以字符串"true"表示邮箱已验证的提供方
+func TestOIDCEmailVerifiedString(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	provider.emailVerified = "true"
+	setupTest(t)
+
+	start := doJSON("GET", "/api/v1/auth/oidc/mock/login", "", nil)
+	code, state := provider.authorize(t, start.Header().Get("Location"), "subject-6", "stringtrue@example.com")
+	w := oidcCallback(start, code, state)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var login loginResponse
+	json.Unmarshal(w.Body.Bytes(), &login)
+
+	var user models.User
+	assert.NoError(t, testDB.First(&user, login.User.ID).Error)
+	assert.True(t, user.EmailVerified)
+}
+
+// TestOIDCInvalidCode 测试篡改授权码和未知提供方
+func TestOIDCInvalidCode(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	setupTest(t)
+
+	start := doJSON("GET", "/api/v1/auth/oidc/mock/login", "", nil)
+	_, state := provider.authorize(t, start.Header().Get("Location"), "subject-3", "bad@example.com")
+	w := oidcCallback(start, "forged", state)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON("GET", "/api/v1/auth/oidc/unknown/login", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestOIDCIssuerTrailingSlash 测试以"/"结尾的发行方（如Auth0）可以完成发现和ID令牌校验
+func TestOIDCIssuerTrailingSlash(t *testing.T) {
+	provider := newMockOIDCProvider(t)
+	provider.issuer = provider.server.URL + "/"
+	t.Setenv("OIDC_MOCK_ISSUER", provider.issuer)
+	setupTest(t)
+
+	start := doJSON("GET", "/api/v1/auth/oidc/mock/login", "", nil)
+	assert.Equal(t, http.StatusFound, start.Code, start.Body.String())
+	code, state := provider.authorize(t, start.Header().Get("Location"), "subject-4", "slash@example.com")
+	w := oidcCallback(start, code, state)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
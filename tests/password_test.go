@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"blog-backend/services"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var tokenPattern = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+// useOutbox 将邮件发送器替换为写入临时目录的本地发件箱
+func useOutbox(t *testing.T) string {
+	dir := t.TempDir()
+	services.SetMailer(&services.OutboxMailer{Dir: dir, From: "test@blog.local"})
+	t.Cleanup(func() {
+		services.SetMailer(nil)
+	})
+	return dir
+}
+
+// lastMailToken 从发件箱中最新的一封邮件里提取令牌
+func lastMailToken(t *testing.T, dir string) string {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if !assert.NotEmpty(t, files) {
+		return ""
+	}
+	sort.Strings(files)
+	data, err := os.ReadFile(files[len(files)-1])
+	assert.NoError(t, err)
+
+	match := tokenPattern.FindStringSubmatch(string(data))
+	if !assert.Len(t, match, 2) {
+		return ""
+	}
+	return match[1]
+}
+
+// TestPasswordReset 测试忘记密码和重置密码流程
+func TestPasswordReset(t *testing.T) {
+	setupTest(t)
+	login := registerAndLogin(t, "resetuser")
+	sent, _ := filepath.Glob(filepath.Join(testOutbox, "*.eml"))
+
+	// 未注册的邮箱同样返回成功，但不发送邮件
+	w := doJSON("POST", "/api/v1/auth/password/forgot", "", models.ForgotPasswordRequest{Email: "nobody@example.com"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	files, _ := filepath.Glob(filepath.Join(testOutbox, "*.eml"))
+	assert.Len(t, files, len(sent))
+
+	w = doJSON("POST", "/api/v1/auth/password/forgot", "", models.ForgotPasswordRequest{Email: "resetuser@example.com"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	token := lastMailToken(t, testOutbox)
+
+	w = doJSON("POST", "/api/v1/auth/password/reset", "", models.ResetPasswordRequest{Token: token, Password: "newpassword456"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 令牌只能使用一次
+	w = doJSON("POST", "/api/v1/auth/password/reset", "", models.ResetPasswordRequest{Token: token, Password: "anotherpass789"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 旧会话被吊销，旧密码不可用，新密码可以登录
+	w = doJSON("GET", "/api/v1/user/profile", login.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doJSON("POST", "/api/v1/auth/login", "", models.LoginRequest{Username: "resetuser", Password: "password123"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doJSON("POST", "/api/v1/auth/login", "", models.LoginRequest{Username: "resetuser", Password: "newpassword456"})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
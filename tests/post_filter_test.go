@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// postIDs 返回文章列表中的文章ID
+func postIDs(posts []models.Post) []uint {
+	ids := make([]uint, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	return ids
+}
+
+// TestPostListFilters 测试文章列表的筛选和排序
+func TestPostListFilters(t *testing.T) {
+	setupTest(t)
+	alice := registerAndLogin(t, "alice")
+	bob := registerAndLogin(t, "bob")
+
+	old := createPost(t, alice.Token, models.PostRequest{Title: "Old", Content: "c"})
+	quiet := createPost(t, alice.Token, models.PostRequest{Title: "Quiet", Content: "c"})
+	busy := createPost(t, bob.Token, models.PostRequest{Title: "Busy", Content: "c"})
+	testDB.Model(&models.Post{}).Where("id = ?", old.ID).Update("created_at", time.Date(2020, 1, 15, 12, 0, 0, 0, time.Local))
+
+	comment := func(post models.Post, n int) {
+		for i := 0; i < n; i++ {
+			path := "/api/v1/posts/" + strconv.Itoa(int(post.ID)) + "/comments"
+			assert.Equal(t, http.StatusCreated, doJSON("POST", path, alice.Token, models.CommentRequest{Content: "hi"}).Code)
+		}
+	}
+	comment(busy, 3)
+	comment(old, 1)
+	// 很久以前的评论计入评论数，但不计入热度
+	testDB.Model(&models.Comment{}).Where("post_id = ?", old.ID).Update("created_at", time.Now().AddDate(0, -1, 0))
+	comment(quiet, 1)
+
+	assert.Equal(t, []uint{quiet.ID, old.ID}, postIDs(listPosts(t, "/api/v1/posts?author=alice", "").Posts))
+	assert.Empty(t, listPosts(t, "/api/v1/posts?author=nobody", "").Posts)
+
+	assert.Equal(t, []uint{old.ID}, postIDs(listPosts(t, "/api/v1/posts?from=2020-01-01&to=2020-01-15", "").Posts))
+	assert.Equal(t, []uint{busy.ID, quiet.ID}, postIDs(listPosts(t, "/api/v1/posts?from=2021-01-01T00:00:00Z", "").Posts))
+
+	testDB.Where("post_id = ?", quiet.ID).Delete(&models.Comment{})
+	assert.Equal(t, []uint{quiet.ID}, postIDs(listPosts(t, "/api/v1/posts?has_comments=false", "").Posts))
+	assert.Len(t, listPosts(t, "/api/v1/posts?has_comments=true", "").Posts, 2)
+
+	byComments := listPosts(t, "/api/v1/posts?sort=comments", "").Posts
+	assert.Equal(t, []uint{busy.ID, old.ID, quiet.ID}, postIDs(byComments))
+	assert.Equal(t, int64(3), byComments[0].CommentCount)
+	assert.Equal(t, []uint{quiet.ID, old.ID, busy.ID}, postIDs(listPosts(t, "/api/v1/posts?sort=comments&order=asc", "").Posts))
+	assert.Equal(t, []uint{busy.ID, quiet.ID, old.ID}, postIDs(listPosts(t, "/api/v1/posts?sort=popular", "").Posts))
+	assert.Equal(t, []uint{old.ID, quiet.ID, busy.ID}, postIDs(listPosts(t, "/api/v1/posts?order=asc", "").Posts))
+
+	// 只能按白名单中的字段排序，游标分页只支持默认排序
+	for _, query := range []string{
+		"sort=title", "sort=created_at%3BDROP%20TABLE%20posts", "order=sideways",
+		"from=yesterday", "to=2020-13-01", "has_comments=maybe", "sort=comments&limit=2",
+	} {
+		assert.Equal(t, http.StatusBadRequest, doJSON("GET", "/api/v1/posts?"+query, "", nil).Code, query)
+	}
+}
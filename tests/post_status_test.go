@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// postListResponse 文章列表响应
+type postListResponse struct {
+	Posts      []models.Post `json:"posts"`
+	Pagination struct {
+		Total int64 `json:"total"`
+	} `json:"pagination"`
+}
+
+// listPosts 获取文章列表
+func listPosts(t *testing.T, path, token string) postListResponse {
+	w := doJSON("GET", path, token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp postListResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+// TestPostLifecycle 测试草稿、发布、归档和撤回
+func TestPostLifecycle(t *testing.T) {
+	setupTest(t)
+	author := registerAndLogin(t, "draftauthor")
+	other := registerAndLogin(t, "draftreader")
+
+	w := doJSON("POST", "/api/v1/posts/", author.Token, models.PostRequest{Title: "Draft", Content: "content", Status: models.PostStatusDraft})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Post models.Post `json:"post"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	assert.Equal(t, models.PostStatusDraft, created.Post.Status)
+	assert.Nil(t, created.Post.PublishedAt)
+	path := "/api/v1/posts/" + strconv.Itoa(int(created.Post.ID))
+
+	// 草稿只有作者可见
+	assert.Equal(t, int64(0), listPosts(t, "/api/v1/posts", "").Pagination.Total)
+	assert.Equal(t, int64(0), listPosts(t, "/api/v1/posts", other.Token).Pagination.Total)
+	assert.Equal(t, int64(1), listPosts(t, "/api/v1/posts?status=draft", author.Token).Pagination.Total)
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", path, "", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", path, other.Token, nil).Code)
+	assert.Equal(t, http.StatusOK, doJSON("GET", path, author.Token, nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON("POST", path+"/publish", other.Token, nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON("POST", path+"/comments", other.Token, models.CommentRequest{Content: "hi"}).Code)
+
+	// 发布后所有人可见
+	w = doJSON("POST", path+"/publish", author.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var published struct {
+		Post models.Post `json:"post"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &published)
+	assert.NotNil(t, published.Post.PublishedAt)
+	assert.Equal(t, int64(1), listPosts(t, "/api/v1/posts", "").Pagination.Total)
+	assert.Equal(t, http.StatusOK, doJSON("GET", path, "", nil).Code)
+
+	// 归档后不出现在列表中，但仍可访问，不能再评论
+	assert.Equal(t, http.StatusOK, doJSON("POST", path+"/archive", author.Token, nil).Code)
+	assert.Equal(t, int64(0), listPosts(t, "/api/v1/posts", "").Pagination.Total)
+	assert.Equal(t, http.StatusOK, doJSON("GET", path, "", nil).Code)
+	assert.Equal(t, http.StatusForbidden, doJSON("POST", path+"/comments", other.Token, models.CommentRequest{Content: "hi"}).Code)
+
+	// 撤回为草稿
+	assert.Equal(t, http.StatusOK, doJSON("POST", path+"/unpublish", author.Token, nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", path, "", nil).Code)
+	assert.Equal(t, http.StatusConflict, doJSON("POST", path+"/archive", author.Token, nil).Code)
+}
@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// profileResponse 个人信息响应
+type profileResponse struct {
+	User struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		DisplayName   string `json:"display_name"`
+		Bio           string `json:"bio"`
+		Website       string `json:"website"`
+	} `json:"user"`
+}
+
+// strPtr 返回字符串指针
+func strPtr(s string) *string {
+	return &s
+}
+
+// TestUpdateProfile 测试PATCH部分更新和PUT整体替换个人资料
+func TestUpdateProfile(t *testing.T) {
+	setupTest(t)
+	login := registerAndLogin(t, "profileuser")
+
+	w := doJSON("PATCH", "/api/v1/user/profile", login.Token, models.ProfileRequest{DisplayName: strPtr("Profile User")})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON("PATCH", "/api/v1/user/profile", login.Token, models.ProfileRequest{Website: strPtr("https://example.com")})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var profile profileResponse
+	w = doJSON("GET", "/api/v1/user/profile", login.Token, nil)
+	json.Unmarshal(w.Body.Bytes(), &profile)
+	assert.Equal(t, "Profile User", profile.User.DisplayName)
+	assert.Equal(t, "https://example.com", profile.User.Website)
+
+	// PUT未提供的字段被清空
+	w = doJSON("PUT", "/api/v1/user/profile", login.Token, models.ProfileRequest{Bio: strPtr("Hello")})
+	assert.Equal(t, http.StatusOK, w.Code)
+	profile = profileResponse{}
+	json.Unmarshal(w.Body.Bytes(), &profile)
+	assert.Equal(t, "", profile.User.DisplayName)
+	assert.Equal(t, "Hello", profile.User.Bio)
+
+	w = doJSON("PATCH", "/api/v1/user/profile", login.Token, models.ProfileRequest{Website: strPtr("javascript:alert(1)")})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestChangePassword 测试修改密码后旧会话失效
+func TestChangePassword(t *testing.T) {
+	setupTest(t)
+	login := registerAndLogin(t, "pwuser")
+
+	w := doJSON("POST", "/api/v1/user/password", login.Token, models.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "newpassword"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = doJSON("POST", "/api/v1/user/password", login.Token, models.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "newpassword"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var changed loginResponse
+	json.Unmarshal(w.Body.Bytes(), &changed)
+
+	w = doJSON("GET", "/api/v1/user/profile", login.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doJSON("GET", "/api/v1/user/profile", changed.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = doJSON("POST", "/api/v1/auth/login", "", models.LoginRequest{Username: "pwuser", Password: "newpassword"})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestChangeEmail 测试修改邮箱需要验证新邮箱后才生效
+func TestChangeEmail(t *testing.T) {
+	setupTest(t)
+	login := registerAndLogin(t, "emailuser")
+	registerAndLogin(t, "takenuser")
+
+	w := doJSON("POST", "/api/v1/user/email", login.Token, models.ChangeEmailRequest{Email: "takenuser@example.com", Password: "password123"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = doJSON("POST", "/api/v1/user/email", login.Token, models.ChangeEmailRequest{Email: "new@example.com", Password: "password123"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	token := lastMailToken(t, testOutbox)
+
+	// 验证之前仍然使用原邮箱
+	var profile profileResponse
+	w = doJSON("GET", "/api/v1/user/profile", login.Token, nil)
+	json.Unmarshal(w.Body.Bytes(), &profile)
+	assert.Equal(t, "emailuser@example.com", profile.User.Email)
+
+	w = doJSON("GET", "/api/v1/auth/verify?token="+token, "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON("GET", "/api/v1/user/profile", login.Token, nil)
+	json.Unmarshal(w.Body.Bytes(), &profile)
+	assert.Equal(t, "new@example.com", profile.User.Email)
+	assert.True(t, profile.User.EmailVerified)
+}
+
+// TestChangeEmailPasswordGuessing 测试修改邮箱时的密码猜测与登录共用失败计数
+func TestChangeEmailPasswordGuessing(t *testing.T) {
+	setupTest(t)
+	login := registerAndLogin(t, "stolensession")
+
+	wrong := models.ChangeEmailRequest{Email: "attacker@example.com", Password: "guess"}
+	for i := 0; i < config.GetLoginProtectionConfig().UserFreeAttempts; i++ {
+		w := doJSON("POST", "/api/v1/user/email", login.Token, wrong)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	}
+
+	// 受限期间即使密码正确也被拒绝，登录同样受限
+	w := doJSON("POST", "/api/v1/user/email", login.Token, models.ChangeEmailRequest{Email: "attacker@example.com", Password: "password123"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	w = doJSON("POST", "/api/v1/auth/login", "", models.LoginRequest{Username: "stolensession", Password: "password123"})
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
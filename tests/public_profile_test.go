@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPublicProfile 测试公开资料和作者文章列表
+func TestPublicProfile(t *testing.T) {
+	setupTest(t)
+	author := registerAndLogin(t, "publicauthor")
+	other := registerAndLogin(t, "otherauthor")
+
+	var postID uint
+	for i := 0; i < 2; i++ {
+		w := doJSON("POST", "/api/v1/posts/", author.Token, models.PostRequest{Title: "Post " + strconv.Itoa(i), Content: "content"})
+		var created struct {
+			Post models.Post `json:"post"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &created)
+		postID = created.Post.ID
+	}
+	doJSON("POST", "/api/v1/posts/", other.Token, models.PostRequest{Title: "Other", Content: "content"})
+	doJSON("POST", "/api/v1/posts/"+strconv.Itoa(int(postID))+"/comments", author.Token, models.CommentRequest{Content: "nice"})
+
+	w := doJSON("GET", "/api/v1/users/publicauthor", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "publicauthor@example.com")
+	var profile struct {
+		User struct {
+			Username     string `json:"username"`
+			PostCount    int64  `json:"post_count"`
+			CommentCount int64  `json:"comment_count"`
+			JoinedAt     string `json:"joined_at"`
+		} `json:"user"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &profile)
+	assert.Equal(t, int64(2), profile.User.PostCount)
+	assert.Equal(t, int64(1), profile.User.CommentCount)
+	assert.NotEmpty(t, profile.User.JoinedAt)
+
+	// 文章转为草稿后，文章下的评论也不再计入公开资料
+	testDB.Model(&models.Post{}).Where("id = ?", postID).Update("status", models.PostStatusDraft)
+	w = doJSON("GET", "/api/v1/users/publicauthor", "", nil)
+	json.Unmarshal(w.Body.Bytes(), &profile)
+	assert.Equal(t, int64(1), profile.User.PostCount)
+	assert.Equal(t, int64(0), profile.User.CommentCount)
+	testDB.Model(&models.Post{}).Where("id = ?", postID).Update("status", models.PostStatusPublished)
+
+	w = doJSON("GET", "/api/v1/users/publicauthor/posts?page_size=1", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Posts      []models.Post `json:"posts"`
+		Pagination struct {
+			Total      int64 `json:"total"`
+			TotalPages int64 `json:"total_pages"`
+		} `json:"pagination"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	assert.Len(t, list.Posts, 1)
+	assert.Equal(t, int64(2), list.Pagination.Total)
+	assert.Equal(t, int64(2), list.Pagination.TotalPages)
+
+	w = doJSON("GET", "/api/v1/users/nobody", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doJSON("GET", "/api/v1/users/nobody/posts", "", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
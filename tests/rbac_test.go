@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"blog-backend/services"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// loginAs 以指定角色登录，直接修改数据库中的角色后重新登录以获取新的令牌
+func loginAs(t *testing.T, username, role string) loginResponse {
+	login := registerAndLogin(t, username)
+	testDB.Model(&models.User{}).Where("id = ?", login.User.ID).Update("role", role)
+
+	w := doJSON("POST", "/api/v1/auth/login", "", models.LoginRequest{
+		Username: username,
+		Password: "password123",
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp loginResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+// TestAdminGrantRole 测试管理员授予角色后编辑可以管理他人文章
+func TestAdminGrantRole(t *testing.T) {
+	setupTest(t)
+	admin := loginAs(t, "adminuser", models.RoleAdmin)
+	author := registerAndLogin(t, "authoruser")
+	editor := registerAndLogin(t, "editoruser")
+
+	// 作者发布文章
+	w := doJSON("POST", "/api/v1/posts/", author.Token, models.PostRequest{Title: "Author Post", Content: "content"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Post models.Post `json:"post"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	postPath := "/api/v1/posts/" + strconv.Itoa(int(created.Post.ID))
+
+	// 普通作者不能删除他人文章
+	w = doJSON("DELETE", postPath, editor.Token, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 非管理员不能访问管理接口
+	w = doJSON("PUT", "/api/v1/admin/users/"+strconv.Itoa(int(editor.User.ID))+"/role", editor.Token, models.RoleRequest{Role: models.RoleAdmin})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 管理员授予编辑角色
+	w = doJSON("PUT", "/api/v1/admin/users/"+strconv.Itoa(int(editor.User.ID))+"/role", admin.Token, models.RoleRequest{Role: models.RoleEditor})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 角色变更后旧令牌失效，重新登录后可以删除他人文章
+	w = doJSON("GET", "/api/v1/user/profile", editor.Token, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doJSON("POST", "/api/v1/auth/login", "", models.LoginRequest{Username: "editoruser", Password: "password123"})
+	var relogin loginResponse
+	json.Unmarshal(w.Body.Bytes(), &relogin)
+
+	w = doJSON("DELETE", postPath, relogin.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 撤销角色
+	w = doJSON("DELETE", "/api/v1/admin/users/"+strconv.Itoa(int(editor.User.ID))+"/role", admin.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var user models.User
+	testDB.First(&user, editor.User.ID)
+	assert.Equal(t, models.RoleAuthor, user.Role)
+}
+
+// TestReaderCannotCreatePost 测试读者角色不能发布文章但可以评论
+func TestReaderCannotCreatePost(t *testing.T) {
+	setupTest(t)
+	author := registerAndLogin(t, "postauthor")
+	reader := loginAs(t, "readeruser", models.RoleReader)
+
+	w := doJSON("POST", "/api/v1/posts/", reader.Token, models.PostRequest{Title: "Reader Post", Content: "content"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = doJSON("POST", "/api/v1/posts/", author.Token, models.PostRequest{Title: "Author Post", Content: "content"})
+	var created struct {
+		Post models.Post `json:"post"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+
+	w = doJSON("POST", "/api/v1/posts/"+strconv.Itoa(int(created.Post.ID))+"/comments", reader.Token, models.CommentRequest{Content: "nice"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+// TestLastAdminGuard 测试不能移除最后一个管理员，申请注销中的管理员不计入
+func TestLastAdminGuard(t *testing.T) {
+	setupTest(t)
+	first := loginAs(t, "firstadmin", models.RoleAdmin)
+	second := loginAs(t, "secondadmin", models.RoleAdmin)
+	rolePath := func(id uint) string { return "/api/v1/admin/users/" + strconv.Itoa(int(id)) + "/role" }
+
+	// 另一个管理员申请注销后，当前管理员不能降级，也不能再申请注销
+	w := doJSON("DELETE", "/api/v1/user", second.Token, models.DeleteAccountRequest{Password: "password123"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, http.StatusConflict, doJSON("DELETE", rolePath(first.User.ID), first.Token, nil).Code)
+	w = doJSON("DELETE", "/api/v1/user", first.Token, models.DeleteAccountRequest{Password: "password123"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 撤销注销后可以降级其中一个
+	assert.Equal(t, http.StatusOK, doJSON("POST", "/api/v1/user/deletion/cancel", second.Token, nil).Code)
+	assert.Equal(t, http.StatusOK, doJSON("DELETE", rolePath(second.User.ID), first.Token, nil).Code)
+	assert.Equal(t, http.StatusConflict, doJSON("DELETE", rolePath(first.User.ID), first.Token, nil).Code)
+}
+
+// TestAccessTokenClaimsNotTrusted 测试访问令牌中的用户和角色以数据库为准
+func TestAccessTokenClaimsNotTrusted(t *testing.T) {
+	setupTest(t)
+	author := registerAndLogin(t, "claimauthor")
+	other := registerAndLogin(t, "claimother")
+	resign := func(token string, change jwt.MapClaims) string {
+		claims := jwt.MapClaims{}
+		_, _, err := jwt.NewParser().ParseUnverified(token, claims)
+		assert.NoError(t, err)
+		for key, value := range change {
+			claims[key] = value
+		}
+		signed, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(os.Getenv("JWT_SECRET")))
+		return signed
+	}
+
+	// 令牌中的角色不被信任
+	elevated := resign(author.Token, jwt.MapClaims{"role": models.RoleAdmin})
+	assert.Equal(t, http.StatusForbidden, doJSON("GET", "/api/v1/admin/users", elevated, nil).Code)
+
+	// 会话必须属于令牌中的用户
+	swapped := resign(author.Token, jwt.MapClaims{"id": other.User.ID})
+	assert.Equal(t, http.StatusUnauthorized, doJSON("GET", "/api/v1/user/profile", swapped, nil).Code)
+
+	// 数据库中的角色变化立即生效
+	testDB.Model(&models.User{}).Where("id = ?", author.User.ID).Update("role", models.RoleAdmin)
+	assert.Equal(t, http.StatusOK, doJSON("GET", "/api/v1/admin/users", author.Token, nil).Code)
+}
+
+// TestBootstrapAdmins 测试配置的初始管理员只在启动时提升已注册的账户，注册时不授予
+func TestBootstrapAdmins(t *testing.T) {
+	setupTest(t)
+	t.Setenv("BLOG_ADMIN_USERNAMES", "owner, squatter")
+	owner := registerAndLogin(t, "owner")
+	var user models.User
+	testDB.First(&user, owner.User.ID)
+	assert.Equal(t, models.RoleAuthor, user.Role)
+
+	promoted, err := services.PromoteBootstrapAdmins()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), promoted)
+	testDB.First(&user, owner.User.ID)
+	assert.Equal(t, models.RoleAdmin, user.Role)
+
+	// 启动之后注册的同名账户不会成为管理员
+	squatter := registerAndLogin(t, "squatter")
+	var late models.User
+	testDB.First(&late, squatter.User.ID)
+	assert.Equal(t, models.RoleAuthor, late.Role)
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"blog-backend/services"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// revisionList 历史版本列表响应
+type revisionList struct {
+	Revisions []models.PostRevision `json:"revisions"`
+}
+
+// TestPostRevisions 测试文章的历史版本、差异和回滚
+func TestPostRevisions(t *testing.T) {
+	setupTest(t)
+	author := registerAndLogin(t, "reviser")
+	other := registerAndLogin(t, "stranger")
+
+	post := createPost(t, author.Token, models.PostRequest{Title: "V1", Content: "intro\nold line\nend\n"})
+	path := "/api/v1/posts/" + strconv.Itoa(int(post.ID))
+	assert.Equal(t, http.StatusOK, doJSON("PUT", path, author.Token, models.PostRequest{Title: "V2", Content: "intro\nnew line\nend\n"}).Code)
+	assert.Equal(t, http.StatusOK, doJSON("PUT", path, author.Token, models.PostRequest{Title: "V3", Content: "intro\nnew line\nend\nmore\n"}).Code)
+
+	w := doJSON("GET", path+"/revisions", author.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list revisionList
+	json.Unmarshal(w.Body.Bytes(), &list)
+	assert.Len(t, list.Revisions, 3)
+	assert.Equal(t, 3, list.Revisions[0].Number)
+	assert.Equal(t, "V3", list.Revisions[0].Title)
+	assert.Equal(t, author.User.ID, *list.Revisions[0].UserID)
+
+	// 默认与上一个版本比较
+	w = doJSON("GET", path+"/revisions/2/diff", author.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var diff services.RevisionDiff
+	json.Unmarshal(w.Body.Bytes(), &diff)
+	assert.Equal(t, 1, diff.From)
+	assert.Equal(t, "V1", diff.FromTitle)
+	assert.Equal(t, "--- revision 1\n+++ revision 2\n@@ -1,3 +1,3 @@\n intro\n-old line\n+new line\n end\n", diff.Diff)
+
+	w = doJSON("GET", path+"/revisions/3/diff?against=1", author.Token, nil)
+	json.Unmarshal(w.Body.Bytes(), &diff)
+	assert.Equal(t, "--- revision 1\n+++ revision 3\n@@ -1,3 +1,4 @@\n intro\n-old line\n+new line\n end\n+more\n", diff.Diff)
+
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", path+"/revisions/9/diff", author.Token, nil).Code)
+	assert.Equal(t, http.StatusBadRequest, doJSON("GET", path+"/revisions/x/diff", author.Token, nil).Code)
+	assert.Equal(t, http.StatusForbidden, doJSON("GET", path+"/revisions", other.Token, nil).Code)
+	assert.Equal(t, http.StatusForbidden, doJSON("POST", path+"/revisions/1/restore", other.Token, nil).Code)
+
+	// 回滚会重新渲染正文并记录为新版本
+	w = doJSON("POST", path+"/revisions/1/restore", author.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var restored struct {
+		Post models.Post `json:"post"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &restored)
+	assert.Equal(t, "V1", restored.Post.Title)
+	assert.Equal(t, "intro\nold line\nend\n", restored.Post.Content)
+	assert.Equal(t, "<p>intro\nold line\nend</p>\n", restored.Post.ContentHTML)
+	assert.Equal(t, post.Slug, restored.Post.Slug)
+
+	w = doJSON("GET", path+"/revisions", author.Token, nil)
+	json.Unmarshal(w.Body.Bytes(), &list)
+	assert.Len(t, list.Revisions, 4)
+	assert.Equal(t, 1, *list.Revisions[0].RestoredFrom)
+
+	// 别人的草稿不可见
+	draft := createPost(t, author.Token, models.PostRequest{Title: "Secret", Content: "c", Status: models.PostStatusDraft})
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", "/api/v1/posts/"+strconv.Itoa(int(draft.ID))+"/revisions", other.Token, nil).Code)
+}
+
+// TestLegacyPostRevision 测试没有历史版本的旧文章在第一次修改时保存原内容
+func TestLegacyPostRevision(t *testing.T) {
+	setupTest(t)
+	author := registerAndLogin(t, "legacy-author")
+	post := models.Post{Title: "Legacy", Content: "original", Status: models.PostStatusPublished, UserID: author.User.ID}
+	assert.NoError(t, testDB.Create(&post).Error)
+
+	path := "/api/v1/posts/" + strconv.Itoa(int(post.ID))
+	assert.Equal(t, http.StatusOK, doJSON("PUT", path, author.Token, models.PostRequest{Title: "Legacy", Content: "edited"}).Code)
+
+	w := doJSON("GET", path+"/revisions", author.Token, nil)
+	var list revisionList
+	json.Unmarshal(w.Body.Bytes(), &list)
+	assert.Len(t, list.Revisions, 2)
+	assert.Equal(t, "original", list.Revisions[1].Content)
+	assert.Equal(t, "edited", list.Revisions[0].Content)
+}
+
+// TestRevisionDiffTooLarge 测试差异过大的版本比较返回422，与空文档比较不受限制
+func TestRevisionDiffTooLarge(t *testing.T) {
+	setupTest(t)
+	author := registerAndLogin(t, "bulkreviser")
+	lines := func(prefix string) string {
+		var b strings.Builder
+		for i := 0; i < 3000; i++ {
+			fmt.Fprintf(&b, "%s %d\n", prefix, i)
+		}
+		return b.String()
+	}
+
+	post := createPost(t, author.Token, models.PostRequest{Title: "Bulk", Content: lines("first")})
+	path := "/api/v1/posts/" + strconv.Itoa(int(post.ID))
+	assert.Equal(t, http.StatusOK, doJSON("PUT", path, author.Token, models.PostRequest{Title: "Bulk", Content: lines("second")}).Code)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, doJSON("GET", path+"/revisions/2/diff", author.Token, nil).Code)
+	assert.Equal(t, http.StatusOK, doJSON("GET", path+"/revisions/2/diff?against=0", author.Token, nil).Code)
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"blog-backend/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestScheduledPublishing 测试设置定时发布、查看待发布草稿和到期发布
+func TestScheduledPublishing(t *testing.T) {
+	setupTest(t)
+	author := registerAndLogin(t, "scheduler")
+	other := registerAndLogin(t, "schedreader")
+
+	// 定时时间必须在未来
+	past := time.Now().Add(-time.Hour)
+	w := doJSON("POST", "/api/v1/posts/", author.Token, models.PostRequest{Title: "Past", Content: "content", ScheduledAt: &past})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	at := time.Now().Add(time.Hour).Truncate(time.Second)
+	w = doJSON("POST", "/api/v1/posts/", author.Token, models.PostRequest{Title: "Later", Content: "content", ScheduledAt: &at})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Post models.Post `json:"post"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	assert.Equal(t, models.PostStatusDraft, created.Post.Status)
+	path := "/api/v1/posts/" + strconv.Itoa(int(created.Post.ID))
+
+	// 作者可以看到待发布的草稿，其他人看不到
+	w = doJSON("GET", "/api/v1/user/posts/scheduled", author.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"Later"`)
+	w = doJSON("GET", "/api/v1/user/posts/scheduled", other.Token, nil)
+	assert.NotContains(t, w.Body.String(), `"title":"Later"`)
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", path, other.Token, nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON("PUT", path+"/schedule", other.Token, models.ScheduleRequest{ScheduledAt: at}).Code)
+
+	// 未到期时不发布
+	published, err := services.NewPostService().PublishDuePosts(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), published)
+
+	published, err = services.NewPostService().PublishDuePosts(at.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), published)
+
+	w = doJSON("GET", path, "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var post models.Post
+	json.Unmarshal(w.Body.Bytes(), &post)
+	assert.Equal(t, models.PostStatusPublished, post.Status)
+	assert.Nil(t, post.ScheduledAt)
+	assert.True(t, at.Equal(*post.PublishedAt))
+
+	// 已发布的文章不能再设置定时发布
+	w = doJSON("PUT", path+"/schedule", author.Token, models.ScheduleRequest{ScheduledAt: at.Add(time.Hour)})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 撤回后重新定时，再取消定时
+	assert.Equal(t, http.StatusOK, doJSON("POST", path+"/unpublish", author.Token, nil).Code)
+	assert.Equal(t, http.StatusOK, doJSON("PUT", path+"/schedule", author.Token, models.ScheduleRequest{ScheduledAt: at}).Code)
+	assert.Equal(t, http.StatusOK, doJSON("DELETE", path+"/schedule", author.Token, nil).Code)
+	published, _ = services.NewPostService().PublishDuePosts(at.Add(time.Minute))
+	assert.Equal(t, int64(0), published)
+}
+
+// TestJobLease 测试多个实例之间的任务租约
+func TestJobLease(t *testing.T) {
+	setupTest(t)
+	now := time.Now()
+
+	acquired, err := services.AcquireLease("job", "instance-a", time.Minute, now)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	// 租约有效期内其它实例无法获取，持有者可以续期
+	acquired, _ = services.AcquireLease("job", "instance-b", time.Minute, now.Add(30*time.Second))
+	assert.False(t, acquired)
+	acquired, _ = services.AcquireLease("job", "instance-a", time.Minute, now.Add(30*time.Second))
+	assert.True(t, acquired)
+
+	// 持有者停止续期后，租约过期可被其它实例接管
+	acquired, _ = services.AcquireLease("job", "instance-b", time.Minute, now.Add(2*time.Minute))
+	assert.True(t, acquired)
+	acquired, _ = services.AcquireLease("job", "instance-a", time.Minute, now.Add(2*time.Minute))
+	assert.False(t, acquired)
+
+	// 调度器只在获取租约后执行任务
+	runs := 0
+	job := services.Job{Name: "job", Interval: time.Minute, Run: func(time.Time) error {
+		runs++
+		return nil
+	}}
+	ran, err := services.NewScheduler().RunJob(job, now.Add(2*time.Minute))
+	assert.NoError(t, err)
+	assert.False(t, ran)
+	ran, _ = services.NewScheduler().RunJob(job, now.Add(5*time.Minute))
+	assert.True(t, ran)
+	assert.Equal(t, 1, runs)
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/services"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// searchResponse 搜索接口响应
+type searchResponse struct {
+	Results    []services.SearchResult `json:"results"`
+	Pagination struct {
+		Total int64 `json:"total"`
+	} `json:"pagination"`
+}
+
+// search 调用搜索接口
+func search(t *testing.T, query, token string) searchResponse {
+	w := doJSON("GET", "/api/v1/search?"+query, token, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp searchResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+// TestSearch 测试搜索的可见性、高亮、短语和前缀查询以及索引同步
+func TestSearch(t *testing.T) {
+	setupTest(t)
+	author := registerAndLogin(t, "searcher")
+
+	generics := createPost(t, author.Token, models.PostRequest{Title: "Golang generics", Content: "Type parameters arrived in Go 1.18.\n\n<b>golang</b> rocks"})
+	other := createPost(t, author.Token, models.PostRequest{Title: "Cooking pasta", Content: "Boil water, then write some golang while waiting."})
+	createPost(t, author.Token, models.PostRequest{Title: "Secret golang plans", Content: "draft", Status: models.PostStatusDraft})
+	w := doJSON("POST", "/api/v1/posts/"+strconv.Itoa(int(other.ID))+"/comments", author.Token, models.CommentRequest{Content: "Great golang tips"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	// 草稿只有作者能搜到
+	resp := search(t, "q=golang", "")
+	assert.Equal(t, int64(3), resp.Pagination.Total)
+	assert.Equal(t, int64(4), search(t, "q=golang", author.Token).Pagination.Total)
+
+	// 命中的词被高亮，正文中的HTML被转义
+	for _, result := range resp.Results {
+		if result.Type == services.SearchTypePost && result.ID == generics.ID {
+			assert.Equal(t, "<mark>Golang</mark> generics", result.Title)
+			assert.Contains(t, result.Snippet, "&lt;b&gt;<mark>golang</mark>&lt;/b&gt;")
+			assert.Equal(t, generics.Slug, result.PostSlug)
+		}
+	}
+
+	comments := search(t, "q=golang&type=comment", "")
+	assert.Len(t, comments.Results, 1)
+	assert.Equal(t, other.ID, comments.Results[0].PostID)
+	assert.Equal(t, "Cooking pasta", comments.Results[0].Title)
+
+	assert.Equal(t, int64(1), search(t, "q=gener*", "").Pagination.Total)
+	assert.Equal(t, int64(1), search(t, "q="+url.QueryEscape(`"cooking pasta"`), "").Pagination.Total)
+	assert.Equal(t, int64(0), search(t, "q="+url.QueryEscape(`"pasta cooking"`), "").Pagination.Total)
+	assert.Len(t, search(t, "q=golang&page_size=1&page=2", "").Results, 1)
+
+	// 标题命中的文章排在正文命中的前面
+	posts := search(t, "q=golang&type=post", "")
+	assert.Equal(t, generics.ID, posts.Results[0].ID)
+	assert.Greater(t, posts.Results[0].Score, posts.Results[1].Score)
+	assert.Greater(t, posts.Results[1].Score, 0.0)
+
+	// 更新和删除文章后索引同步
+	path := "/api/v1/posts/" + strconv.Itoa(int(other.ID))
+	assert.Equal(t, http.StatusOK, doJSON("PUT", path, author.Token, models.PostRequest{Title: "Cooking risotto", Content: "Stir constantly."}).Code)
+	assert.Equal(t, int64(1), search(t, "q=risotto", "").Pagination.Total)
+	assert.Equal(t, int64(0), search(t, "q=pasta", "").Pagination.Total)
+	assert.Equal(t, http.StatusOK, doJSON("DELETE", path, author.Token, nil).Code)
+	assert.Equal(t, int64(0), search(t, "q=risotto", "").Pagination.Total)
+	assert.Equal(t, int64(0), search(t, "q=tips", "").Pagination.Total)
+
+	for _, query := range []string{"q=", "q=" + url.QueryEscape("!!! ***"), "q=golang&type=user"} {
+		assert.Equal(t, http.StatusBadRequest, doJSON("GET", "/api/v1/search?"+query, "", nil).Code, query)
+	}
+}
+
+// TestParseSearchQuery 测试搜索语句的解析
+func TestParseSearchQuery(t *testing.T) {
+	terms, err := services.ParseSearchQuery(`go "type  parameters"* gene* "unclosed`)
+	assert.NoError(t, err)
+	assert.Equal(t, []services.SearchTerm{
+		{Text: "go"},
+		{Text: "type parameters", Prefix: true},
+		{Text: "gene", Prefix: true},
+		{Text: "unclosed"},
+	}, terms)
+
+	_, err = services.ParseSearchQuery(`"" * -`)
+	assert.Error(t, err)
+}
+
+// TestSearchLikeEngine 测试明确配置LIKE查询时不建索引，结果按时间倒序
+func TestSearchLikeEngine(t *testing.T) {
+	t.Setenv("SEARCH_ENGINE", config.SearchEngineLike)
+	setupTest(t)
+	author := registerAndLogin(t, "liker")
+	older := createPost(t, author.Token, models.PostRequest{Title: "Golang first", Content: "c"})
+	newer := createPost(t, author.Token, models.PostRequest{Title: "Second", Content: "about golang"})
+
+	assert.False(t, testDB.Migrator().HasTable(config.SearchIndexTable))
+	resp := search(t, "q=golang", "")
+	assert.Equal(t, int64(2), resp.Pagination.Total)
+	assert.Equal(t, []uint{newer.ID, older.ID}, []uint{resp.Results[0].ID, resp.Results[1].ID})
+}
+
+// TestSearchEngineConfig 测试未知的搜索引擎配置导致迁移失败
+func TestSearchEngineConfig(t *testing.T) {
+	t.Setenv("SEARCH_ENGINE", "elastic")
+	db, err := gorm.Open(config.SQLiteDialector(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.Error(t, config.MigrateDB(db))
+}
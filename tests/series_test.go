@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// seriesResponse 系列接口响应
+type seriesResponse struct {
+	Series models.Series `json:"series"`
+}
+
+// seriesPostIDs 返回系列中文章的ID顺序
+func seriesPostIDs(series models.Series) []uint {
+	ids := []uint{}
+	for _, post := range series.Posts {
+		ids = append(ids, post.ID)
+	}
+	return ids
+}
+
+// TestSeriesNavigation 测试创建系列、添加和排序文章，以及文章详情中的上一篇/下一篇
+func TestSeriesNavigation(t *testing.T) {
+	setupTest(t)
+	author := registerAndLogin(t, "seriesauthor")
+	other := registerAndLogin(t, "seriesother")
+
+	w := doJSON("POST", "/api/v1/series", author.Token, models.SeriesRequest{Title: "Go Tutorial", Description: "Learn Go"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created seriesResponse
+	json.Unmarshal(w.Body.Bytes(), &created)
+	assert.Equal(t, "go-tutorial", created.Series.Slug)
+	base := "/api/v1/series/" + strconv.Itoa(int(created.Series.ID))
+
+	part1 := createPost(t, author.Token, models.PostRequest{Title: "Part 1", Content: "c"})
+	part2 := createPost(t, author.Token, models.PostRequest{Title: "Part 2", Content: "c"})
+	part3 := createPost(t, author.Token, models.PostRequest{Title: "Part 3", Content: "c", Status: models.PostStatusDraft})
+	foreign := createPost(t, other.Token, models.PostRequest{Title: "Foreign", Content: "c"})
+
+	for _, post := range []models.Post{part2, part3} {
+		assert.Equal(t, http.StatusOK, doJSON("POST", base+"/posts", author.Token, models.SeriesPostRequest{PostID: post.ID}).Code)
+	}
+	w = doJSON("POST", base+"/posts", author.Token, models.SeriesPostRequest{PostID: part1.ID, Position: 1})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var added seriesResponse
+	json.Unmarshal(w.Body.Bytes(), &added)
+	assert.Equal(t, []uint{part1.ID, part2.ID, part3.ID}, seriesPostIDs(added.Series))
+
+	// 不能添加他人的文章，也不能重复添加；其他人不能管理系列
+	assert.Equal(t, http.StatusForbidden, doJSON("POST", base+"/posts", author.Token, models.SeriesPostRequest{PostID: foreign.ID}).Code)
+	assert.Equal(t, http.StatusConflict, doJSON("POST", base+"/posts", author.Token, models.SeriesPostRequest{PostID: part1.ID}).Code)
+	assert.Equal(t, http.StatusForbidden, doJSON("PUT", base, other.Token, models.SeriesRequest{Title: "Hijack"}).Code)
+
+	// 匿名读者看不到草稿，导航跳过草稿
+	w = doJSON("GET", "/api/v1/series/by-slug/go-tutorial", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var public seriesResponse
+	json.Unmarshal(w.Body.Bytes(), &public)
+	assert.Equal(t, []uint{part1.ID, part2.ID}, seriesPostIDs(public.Series))
+
+	w = doJSON("GET", "/api/v1/posts/"+strconv.Itoa(int(part2.ID)), "", nil)
+	var post models.Post
+	json.Unmarshal(w.Body.Bytes(), &post)
+	assert.Equal(t, "go-tutorial", post.SeriesInfo.Slug)
+	assert.Equal(t, 2, post.SeriesInfo.Position)
+	assert.Equal(t, 2, post.SeriesInfo.Total)
+	assert.Equal(t, part1.ID, post.SeriesInfo.Previous.ID)
+	assert.Nil(t, post.SeriesInfo.Next)
+
+	// 作者可以看到草稿作为下一篇
+	w = doJSON("GET", "/api/v1/posts/"+strconv.Itoa(int(part2.ID)), author.Token, nil)
+	json.Unmarshal(w.Body.Bytes(), &post)
+	assert.Equal(t, part3.ID, post.SeriesInfo.Next.ID)
+
+	// 重新排序必须包含全部文章
+	w = doJSON("PUT", base+"/posts/order", author.Token, models.SeriesOrderRequest{PostIDs: []uint{part2.ID, part1.ID}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON("PUT", base+"/posts/order", author.Token, models.SeriesOrderRequest{PostIDs: []uint{part3.ID, part2.ID, part1.ID}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var reordered seriesResponse
+	json.Unmarshal(w.Body.Bytes(), &reordered)
+	assert.Equal(t, []uint{part3.ID, part2.ID, part1.ID}, seriesPostIDs(reordered.Series))
+
+	// 删除文章后其余文章重新编号
+	assert.Equal(t, http.StatusOK, doJSON("DELETE", "/api/v1/posts/"+strconv.Itoa(int(part2.ID)), author.Token, nil).Code)
+	w = doJSON("GET", "/api/v1/posts/"+strconv.Itoa(int(part1.ID)), author.Token, nil)
+	json.Unmarshal(w.Body.Bytes(), &post)
+	assert.Equal(t, 2, post.SeriesPosition)
+	assert.Equal(t, part3.ID, post.SeriesInfo.Previous.ID)
+
+	// 移出文章和删除系列
+	assert.Equal(t, http.StatusOK, doJSON("DELETE", base+"/posts/"+strconv.Itoa(int(part3.ID)), author.Token, nil).Code)
+	assert.Equal(t, http.StatusOK, doJSON("DELETE", base, author.Token, nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", base, "", nil).Code)
+	w = doJSON("GET", "/api/v1/posts/"+strconv.Itoa(int(part1.ID)), "", nil)
+	assert.NotContains(t, w.Body.String(), `"series":{`)
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"blog-backend/config"
+	"blog-backend/models"
+	"blog-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createPost 创建文章并返回结果
+func createPost(t *testing.T, token string, req models.PostRequest) models.Post {
+	w := doJSON("POST", "/api/v1/posts/", token, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var resp struct {
+		Post models.Post `json:"post"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp.Post
+}
+
+// TestSlugify 测试标题转换为别名
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "hello-world", utils.Slugify("Hello, World!"))
+	assert.Equal(t, "creme-brulee-a-la-mode", utils.Slugify("Crème Brûlée à la mode"))
+	assert.Equal(t, "strasse", utils.Slugify("Straße"))
+	assert.Equal(t, "go语言-入门教程", utils.Slugify("Go语言 入门教程"))
+	assert.Equal(t, "", utils.Slugify("！！！"))
+	assert.False(t, utils.IsValidSlug("Hello World"))
+	assert.True(t, utils.IsValidSlug("hello-world-2"))
+}
+
+// TestPostSlugs 测试别名生成、冲突处理、自定义别名和旧别名重定向
+func TestPostSlugs(t *testing.T) {
+	setupTest(t)
+	user := registerAndLogin(t, "slugger")
+
+	first := createPost(t, user.Token, models.PostRequest{Title: "Hello World", Content: "content"})
+	assert.Equal(t, "hello-world", first.Slug)
+	second := createPost(t, user.Token, models.PostRequest{Title: "Hello, World!", Content: "content"})
+	assert.Equal(t, "hello-world-2", second.Slug)
+	chinese := createPost(t, user.Token, models.PostRequest{Title: "你好世界", Content: "content"})
+	assert.Equal(t, "你好世界", chinese.Slug)
+	symbols := createPost(t, user.Token, models.PostRequest{Title: "？？", Content: "content"})
+	assert.Equal(t, "post-"+strconv.Itoa(int(symbols.ID)), symbols.Slug)
+
+	w := doJSON("GET", "/api/v1/posts/by-slug/"+url.PathEscape(chinese.Slug), "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"你好世界"`)
+
+	// 自定义别名必须规范且未被使用
+	w = doJSON("POST", "/api/v1/posts/", user.Token, models.PostRequest{Title: "x", Content: "c", Slug: "Bad Slug"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doJSON("POST", "/api/v1/posts/", user.Token, models.PostRequest{Title: "x", Content: "c", Slug: "hello-world"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// 修改别名后旧地址301重定向到新地址
+	path := "/api/v1/posts/" + strconv.Itoa(int(first.ID))
+	w = doJSON("PUT", path, user.Token, models.PostRequest{Title: "Hello World", Content: "content", Slug: "greetings"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON("GET", "/api/v1/posts/by-slug/hello-world", "", nil)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/api/v1/posts/by-slug/greetings", w.Header().Get("Location"))
+
+	// 旧别名仍属于原文章，其它文章不能使用，原文章可以改回
+	w = doJSON("PUT", "/api/v1/posts/"+strconv.Itoa(int(second.ID)), user.Token, models.PostRequest{Title: "x", Content: "c", Slug: "hello-world"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = doJSON("PUT", path, user.Token, models.PostRequest{Title: "Hello World", Content: "content", Slug: "hello-world"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusOK, doJSON("GET", "/api/v1/posts/by-slug/hello-world", "", nil).Code)
+	assert.Equal(t, http.StatusMovedPermanently, doJSON("GET", "/api/v1/posts/by-slug/greetings", "", nil).Code)
+
+	// 草稿通过别名同样对其他人不可见
+	draft := createPost(t, user.Token, models.PostRequest{Title: "Secret", Content: "c", Status: models.PostStatusDraft})
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", "/api/v1/posts/by-slug/"+draft.Slug, "", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", "/api/v1/posts/by-slug/missing", "", nil).Code)
+}
+
+// TestBackfillPostSlugs 测试迁移时为已有文章生成别名
+func TestBackfillPostSlugs(t *testing.T) {
+	setupTest(t)
+	user := registerAndLogin(t, "legacy")
+	post := models.Post{Title: "Legacy Post", Content: "c", Status: models.PostStatusPublished, UserID: user.User.ID}
+	assert.NoError(t, testDB.Create(&post).Error)
+
+	assert.NoError(t, config.MigrateDB(testDB))
+	testDB.First(&post, post.ID)
+	assert.Equal(t, "legacy-post-"+strconv.Itoa(int(post.ID)), post.Slug)
+	assert.Equal(t, http.StatusOK, doJSON("GET", "/api/v1/posts/by-slug/"+post.Slug, "", nil).Code)
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"blog-backend/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPostTags 测试标签的规范化、去重、筛选和清理
+func TestPostTags(t *testing.T) {
+	setupTest(t)
+	user := registerAndLogin(t, "tagger")
+
+	first := createPost(t, user.Token, models.PostRequest{Title: "First", Content: "c", Tags: []string{"  Go  Lang ", "go lang", "Web"}})
+	assert.Len(t, first.Tags, 2)
+	assert.Equal(t, "Go Lang", first.Tags[0].Name)
+	assert.Equal(t, "go-lang", first.Tags[0].Slug)
+	createPost(t, user.Token, models.PostRequest{Title: "Second", Content: "c", Tags: []string{"GO LANG"}})
+	createPost(t, user.Token, models.PostRequest{Title: "Draft", Content: "c", Status: models.PostStatusDraft, Tags: []string{"secret"}})
+
+	w := doJSON("POST", "/api/v1/posts/", user.Token, models.PostRequest{Title: "Bad", Content: "c", Tags: []string{"!!!"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 标签列表只统计已发布的文章
+	w = doJSON("GET", "/api/v1/tags", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Tags []services.TagWithCount `json:"tags"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	assert.Len(t, list.Tags, 2)
+	assert.Equal(t, "go-lang", list.Tags[0].Slug)
+	assert.Equal(t, int64(2), list.Tags[0].PostCount)
+	assert.Equal(t, int64(1), list.Tags[1].PostCount)
+
+	assert.Equal(t, int64(2), listPosts(t, "/api/v1/posts?tag=go-lang", "").Pagination.Total)
+	assert.Equal(t, int64(1), listPosts(t, "/api/v1/tags/web/posts", "").Pagination.Total)
+	assert.Equal(t, int64(0), listPosts(t, "/api/v1/tags/secret/posts", "").Pagination.Total)
+	assert.Equal(t, int64(1), listPosts(t, "/api/v1/tags/secret/posts", user.Token).Pagination.Total)
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", "/api/v1/tags/missing/posts", "", nil).Code)
+
+	// 不提供标签时保持不变，替换标签后清理不再使用的标签
+	path := "/api/v1/posts/" + strconv.Itoa(int(first.ID))
+	w = doJSON("PUT", path, user.Token, models.PostRequest{Title: "First", Content: "updated"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"slug":"web"`)
+	w = doJSON("PUT", path, user.Token, models.PostRequest{Title: "First", Content: "updated", Tags: []string{"Go Lang"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", "/api/v1/tags/web/posts", "", nil).Code)
+
+	// 删除文章后其标签如果不再使用也会被清理
+	draft := listPosts(t, "/api/v1/posts?tag=secret", user.Token).Posts[0]
+	assert.Equal(t, http.StatusOK, doJSON("DELETE", "/api/v1/posts/"+strconv.Itoa(int(draft.ID)), user.Token, nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON("GET", "/api/v1/tags/secret/posts", user.Token, nil).Code)
+}
@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPersonalAccessToken 测试个人访问令牌的创建、权限范围和吊销
+func TestPersonalAccessToken(t *testing.T) {
+	setupTest(t)
+	login := registerAndLogin(t, "patuser")
+
+	w := doJSON("POST", "/api/v1/user/tokens", login.Token, models.PersonalAccessTokenRequest{
+		Name:   "ci",
+		Scopes: []string{"posts:write"},
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Token struct {
+			ID    uint   `json:"id"`
+			Token string `json:"token"`
+		} `json:"token"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	pat := created.Token.Token
+	assert.Contains(t, pat, "blog_pat_")
+
+	// 拥有 posts:write 可以发布文章
+	w = doJSON("POST", "/api/v1/posts/", pat, models.PostRequest{Title: "From CI", Content: "content"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var post struct {
+		Post models.Post `json:"post"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &post)
+	assert.Equal(t, login.User.ID, post.Post.UserID)
+
+	// 超出权限范围的操作被拒绝
+	w = doJSON("POST", "/api/v1/posts/"+strconv.Itoa(int(post.Post.ID))+"/comments", pat, models.CommentRequest{Content: "hi"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = doJSON("GET", "/api/v1/user/profile", pat, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 个人访问令牌不能管理令牌
+	w = doJSON("GET", "/api/v1/user/tokens", pat, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 列表中不包含明文令牌，并记录了最近使用时间
+	w = doJSON("GET", "/api/v1/user/tokens", login.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), pat)
+	var list struct {
+		Tokens []struct {
+			Name       string     `json:"name"`
+			Scopes     []string   `json:"scopes"`
+			LastUsedAt *time.Time `json:"last_used_at"`
+		} `json:"tokens"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &list)
+	if assert.Len(t, list.Tokens, 1) {
+		assert.Equal(t, []string{"posts:write"}, list.Tokens[0].Scopes)
+		assert.NotNil(t, list.Tokens[0].LastUsedAt)
+	}
+
+	// 吊销后无法继续使用
+	w = doJSON("DELETE", "/api/v1/user/tokens/"+strconv.Itoa(int(created.Token.ID)), login.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doJSON("POST", "/api/v1/posts/", pat, models.PostRequest{Title: "From CI", Content: "content"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// TestPersonalAccessTokenValidation 测试非法权限范围和已过期的令牌
+func TestPersonalAccessTokenValidation(t *testing.T) {
+	setupTest(t)
+	login := registerAndLogin(t, "patuser2")
+
+	w := doJSON("POST", "/api/v1/user/tokens", login.Token, models.PersonalAccessTokenRequest{
+		Name:   "bad",
+		Scopes: []string{"admin:all"},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	past := time.Now().Add(-time.Hour)
+	w = doJSON("POST", "/api/v1/user/tokens", login.Token, models.PersonalAccessTokenRequest{
+		Name:      "expired",
+		Scopes:    []string{"profile:read"},
+		ExpiresAt: &past,
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"blog-backend/models"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEmailVerification 测试注册后的邮箱验证流程
+func TestEmailVerification(t *testing.T) {
+	setupTest(t)
+	t.Setenv("REQUIRE_EMAIL_VERIFICATION", "true")
+	login := registerAndLogin(t, "verifyuser")
+	token := lastMailToken(t, testOutbox)
+
+	// 新账户未验证，不能发布文章
+	var user models.User
+	testDB.First(&user, login.User.ID)
+	assert.False(t, user.EmailVerified)
+	w := doJSON("POST", "/api/v1/posts/", login.Token, models.PostRequest{Title: "Title", Content: "content"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// 发送过于频繁
+	w = doJSON("POST", "/api/v1/auth/verify/resend", login.Token, nil)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	w = doJSON("GET", "/api/v1/auth/verify?token="+token, "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	testDB.First(&user, login.User.ID)
+	assert.True(t, user.EmailVerified)
+
+	// 令牌只能使用一次
+	w = doJSON("GET", "/api/v1/auth/verify?token="+token, "", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON("POST", "/api/v1/posts/", login.Token, models.PostRequest{Title: "Title", Content: "content"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+// TestResendVerificationPendingEmailChange 测试已验证的用户修改邮箱后，重发验证邮件发送到新邮箱
+func TestResendVerificationPendingEmailChange(t *testing.T) {
+	setupTest(t)
+	login := registerAndLogin(t, "resenduser")
+	w := doJSON("GET", "/api/v1/auth/verify?token="+lastMailToken(t, testOutbox), "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 没有待验证的新邮箱时无需重发
+	testDB.Model(&models.EmailVerificationToken{}).Where("user_id = ?", login.User.ID).Update("created_at", time.Now().Add(-time.Hour))
+	w = doJSON("POST", "/api/v1/auth/verify/resend", login.Token, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doJSON("POST", "/api/v1/user/email", login.Token, models.ChangeEmailRequest{Email: "moved@example.com", Password: "password123"})
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	first := lastMailToken(t, testOutbox)
+
+	testDB.Model(&models.EmailVerificationToken{}).Where("user_id = ?", login.User.ID).Update("created_at", time.Now().Add(-time.Hour))
+	w = doJSON("POST", "/api/v1/auth/verify/resend", login.Token, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	files, _ := filepath.Glob(filepath.Join(testOutbox, "*.eml"))
+	sort.Strings(files)
+	data, _ := os.ReadFile(files[len(files)-1])
+	assert.Contains(t, string(data), "To: moved@example.com")
+
+	// 只有最新的令牌有效，验证后邮箱改为新邮箱
+	assert.Equal(t, http.StatusBadRequest, doJSON("GET", "/api/v1/auth/verify?token="+first, "", nil).Code)
+	w = doJSON("GET", "/api/v1/auth/verify?token="+lastMailToken(t, testOutbox), "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var user models.User
+	testDB.First(&user, login.User.ID)
+	assert.Equal(t, "moved@example.com", user.Email)
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Cursor 键集分页的位置，记录翻页起点那条记录的排序键 (created_at, id)
+type Cursor struct {
+	Scope     string    `json:"s"`           // 游标所属的列表，防止在其它列表中使用
+	CreatedAt time.Time `json:"t"`           // 起点记录的创建时间
+	ID        uint      `json:"i"`           // 起点记录的ID，创建时间相同时用于排序
+	Backward  bool      `json:"b,omitempty"` // true 表示取起点之前（更新）的一页
+}
+
+// EncodeCursor 将游标编码为不透明的字符串：Base64编码的内容加上HMAC-SHA256签名
+func EncodeCursor(cursor Cursor, secret string) string {
+	payload, _ := json.Marshal(cursor)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + cursorSignature(encoded, secret)
+}
+
+// DecodeCursor 校验签名并解码游标，签名不符或内容无效时返回错误
+func DecodeCursor(token, secret string) (Cursor, error) {
+	var cursor Cursor
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(cursorSignature(encoded, secret))) {
+		return cursor, errors.New("invalid cursor")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || json.Unmarshal(payload, &cursor) != nil || cursor.ID == 0 {
+		return cursor, errors.New("invalid cursor")
+	}
+	return cursor, nil
+}
+
+// cursorSignature 计算游标内容的签名
+func cursorSignature(encoded, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
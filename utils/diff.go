@@ -0,0 +1,253 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DiffContextLines 统一格式差异中每个变更块前后保留的相同行数
+const DiffContextLines = 3
+
+// 差异计算的上限：耗时与（行数 × 编辑距离）成正比，去掉相同的首尾后超过任一上限时拒绝计算
+const (
+	MaxDiffLines = 50000 // 两侧合计的行数
+	MaxDiffEdits = 5000  // 新增与删除的行数之和
+)
+
+// ErrDiffTooLarge 两段文本差异过大，超过计算上限
+var ErrDiffTooLarge = errors.New("diff too large")
+
+// diffOp 行级编辑操作，aPos/bPos 为该操作之前两侧已经经过的行数
+type diffOp struct {
+	kind byte // ' ' 相同，'-' 删除，'+' 新增
+	line string
+	aPos int
+	bPos int
+}
+
+// UnifiedDiff 按行比较两段文本，返回统一格式（unified diff）的差异，内容相同时返回空字符串
+// 差异超过计算上限时返回 ErrDiffTooLarge
+func UnifiedDiff(fromName, toName, from, to string) (string, error) {
+	ops, err := diffLines(splitLines(from), splitLines(to))
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// 相邻变更之间的相同行不超过两倍上下文时合并为一个变更块
+		start := max(0, i-DiffContextLines)
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			} else if j-end > 2*DiffContextLines {
+				break
+			}
+		}
+		stop := min(len(ops), end+DiffContextLines+1)
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		aCount, bCount := 0, 0
+		for _, op := range ops[start:stop] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(ops[start].aPos, aCount), hunkRange(ops[start].bPos, bCount))
+		for _, op := range ops[start:stop] {
+			b.WriteByte(op.kind)
+			b.WriteString(op.line)
+			b.WriteByte('\n')
+		}
+		i = stop
+	}
+	return b.String(), nil
+}
+
+// hunkRange 生成变更块头部的行范围，没有行时起始行为变更位置的前一行
+func hunkRange(pos, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", pos)
+	case 1:
+		return fmt.Sprintf("%d", pos+1)
+	}
+	return fmt.Sprintf("%d,%d", pos+1, count)
+}
+
+// splitLines 将文本拆分为行，末尾的换行符不产生空行
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines 计算两组行之间的最短编辑脚本，先去掉相同的首尾，再检查差异大小，最后用线性空间的Myers算法计算
+func diffLines(a, b []string) ([]diffOp, error) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	// 一侧为空（如与空文档比较）时差异就是另一侧的全部行，不受上限限制
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(midA) > 0 && len(midB) > 0 &&
+		(len(midA)+len(midB) > MaxDiffLines || !withinEdits(midA, midB, MaxDiffEdits)) {
+		return nil, ErrDiffTooLarge
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{kind: ' ', line: a[i], aPos: i, bPos: i})
+	}
+	d := &differ{a: a, b: b, ops: ops}
+	d.diff(prefix, len(a)-suffix, prefix, len(b)-suffix)
+	for i := 0; i < suffix; i++ {
+		aPos, bPos := len(a)-suffix+i, len(b)-suffix+i
+		d.ops = append(d.ops, diffOp{kind: ' ', line: a[aPos], aPos: aPos, bPos: bPos})
+	}
+	return d.ops, nil
+}
+
+// withinEdits 判断编辑距离是否不超过limit，只保留每条对角线的最远位置，内存与行数成正比
+func withinEdits(a, b []string, limit int) bool {
+	n, m := len(a), len(b)
+	if n+m <= limit {
+		return true
+	}
+	offset := limit + 1
+	v := make([]int, 2*offset+1)
+	for d := 0; d <= limit; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// differ 一次差异计算的状态，ops按顺序收集编辑操作
+type differ struct {
+	a, b []string
+	ops  []diffOp
+}
+
+// diff 计算 a[aLo:aHi] 与 b[bLo:bHi] 之间的编辑操作：去掉相同的首尾后，
+// 在最短编辑路径的中点处把问题一分为二递归求解（Myers论文4b节），只需要线性空间
+func (d *differ) diff(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.ops = append(d.ops, diffOp{kind: ' ', line: d.a[aLo], aPos: aLo, bPos: bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-1-suffix] == d.b[bHi-1-suffix] {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			d.ops = append(d.ops, diffOp{kind: '+', line: d.b[y], aPos: aLo, bPos: y})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			d.ops = append(d.ops, diffOp{kind: '-', line: d.a[x], aPos: x, bPos: bLo})
+		}
+	default:
+		// 首尾不同且两侧都不为空时编辑距离至少为2，中点严格位于两端之间，递归必然缩小问题
+		x, y := d.middle(aLo, aHi, bLo, bHi)
+		d.diff(aLo, x, bLo, y)
+		d.diff(x, aHi, y, bHi)
+	}
+
+	for i := suffix; i > 0; i-- {
+		d.ops = append(d.ops, diffOp{kind: ' ', line: d.a[aHi+suffix-i], aPos: aHi + suffix - i, bPos: bHi + suffix - i})
+	}
+}
+
+// middle 从两端同时搜索最短编辑路径，两个方向相遇时返回正向路径到达的位置
+// vf[k] 为正向在对角线 k = x-y 上到达的最远x，vb[k] 为反向在对角线 k（从末尾计）上已经经过的行数
+func (d *differ) middle(aLo, aHi, bLo, bHi int) (int, int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	vf := make([]int, 2*offset+1)
+	vb := make([]int, 2*offset+1)
+
+	for step := 0; step <= maxD; step++ {
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && vf[offset+k-1] < vf[offset+k+1]) {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			vf[offset+k] = x
+			// 编辑距离为奇数时在正向搜索中相遇，反向对角线为 delta-k，已搜索到 step-1 步
+			if back := delta - k; odd && back >= -(step-1) && back <= step-1 && x+vb[offset+back] >= n {
+				return aLo + x, bLo + y
+			}
+		}
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || (k != step && vb[offset+k-1] < vb[offset+k+1]) {
+				x = vb[offset+k+1]
+			} else {
+				x = vb[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && d.a[aHi-1-x] == d.b[bHi-1-y] {
+				x++
+				y++
+			}
+			vb[offset+k] = x
+			// 编辑距离为偶数时在反向搜索中相遇，返回同一对角线上正向到达的位置
+			if forward := delta - k; !odd && forward >= -step && forward <= step && x+vf[offset+forward] >= n {
+				fx := vf[offset+forward]
+				return aLo + fx, bLo + fx - forward
+			}
+		}
+	}
+	// 不会到达：maxD步内两个方向必然相遇
+	return aLo + n, bLo + m
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// MarkdownRenderVersion 渲染规则的版本，修改渲染或过滤规则后递增，启动时会重新渲染旧版本的缓存
+const MarkdownRenderVersion = 2
+
+// markdown CommonMark + GFM（表格、删除线、任务列表、自动链接）渲染器
+// 原始HTML原样输出，统一交给白名单过滤
+var markdown = goldmark.New(
+	goldmark.WithExtensions(
+		// 与 extension.GFM 相同，表格的对齐方式输出为 align 属性，过滤时不需要放行 style
+		extension.NewTable(extension.WithTableCellAlignMethod(extension.TableCellAlignAttribute)),
+		extension.Strikethrough,
+		extension.Linkify,
+		extension.TaskList,
+	),
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+// RenderMarkdown 将文章的Markdown渲染为经过白名单过滤的HTML
+func RenderMarkdown(source string) string {
+	return postPolicy.sanitize(markdownToHTML(source))
+}
+
+// RenderCommentMarkdown 将评论的Markdown渲染为HTML，评论只允许段落、强调、代码、引用、列表和链接
+func RenderCommentMarkdown(source string) string {
+	return commentPolicy.sanitize(markdownToHTML(source))
+}
+
+// markdownToHTML 将Markdown渲染为未过滤的HTML
+func markdownToHTML(source string) string {
+	var b bytes.Buffer
+	if err := markdown.Convert([]byte(source), &b); err != nil {
+		return ""
+	}
+	return b.String()
+}
@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlPolicy HTML白名单策略：只保留允许的元素和属性，其它元素去掉标签保留内容
+type htmlPolicy struct {
+	elements map[string][]string // 允许的元素及其允许的属性
+	rename   map[string]string   // 不允许的元素改写为其它元素
+}
+
+// droppedElements 连同内容一起删除的元素
+var droppedElements = map[string]bool{
+	"script": true, "style": true, "iframe": true, "frame": true, "frameset": true, "object": true,
+	"embed": true, "applet": true, "noscript": true, "template": true, "textarea": true, "select": true,
+	"svg": true, "math": true, "title": true, "head": true, "meta": true, "link": true, "base": true,
+}
+
+// voidElements 没有结束标签的元素
+var voidElements = map[string]bool{"br": true, "hr": true, "img": true, "input": true}
+
+// tableElements 表格结构元素
+var tableElements = map[string]bool{"table": true, "thead": true, "tbody": true, "tr": true}
+
+// 允许的URL协议，没有协议的相对地址同样允许
+var safeSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+var codeLanguageClass = regexp.MustCompile(`^language-[A-Za-z0-9_+#-]+$`)
+
+// postPolicy 文章允许的HTML
+var postPolicy = &htmlPolicy{
+	elements: map[string][]string{
+		"p": nil, "br": nil, "hr": nil, "h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+		"strong": nil, "b": nil, "em": nil, "i": nil, "del": nil, "s": nil, "sup": nil, "sub": nil, "kbd": nil,
+		"code": {"class"}, "pre": nil, "blockquote": nil, "ul": nil, "ol": {"start"}, "li": nil,
+		"a": {"href", "title"}, "img": {"src", "alt", "title"},
+		"table": nil, "thead": nil, "tbody": nil, "tr": nil, "th": {"align"}, "td": {"align"},
+		"input": {"type", "checked", "disabled"},
+	},
+}
+
+// commentPolicy 评论允许的HTML：不允许标题、图片和表格，标题改写为段落
+var commentPolicy = &htmlPolicy{
+	elements: map[string][]string{
+		"p": nil, "br": nil, "strong": nil, "b": nil, "em": nil, "i": nil, "del": nil, "s": nil,
+		"code": nil, "pre": nil, "blockquote": nil, "ul": nil, "ol": {"start"}, "li": nil,
+		"a": {"href", "title"},
+	},
+	rename: map[string]string{"h1": "p", "h2": "p", "h3": "p", "h4": "p", "h5": "p", "h6": "p"},
+}
+
+// SanitizeHTML 按文章的白名单过滤HTML
+func SanitizeHTML(fragment string) string {
+	return postPolicy.sanitize(fragment)
+}
+
+// sanitize 解析HTML片段并按白名单重新输出
+func (p *htmlPolicy) sanitize(fragment string) string {
+	context := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), context)
+	if err != nil {
+		return html.EscapeString(fragment)
+	}
+
+	var b strings.Builder
+	p.renderNodes(&b, nodes)
+	return b.String()
+}
+
+// render 输出单个节点，注释等其它类型的节点被丢弃，节点没有任何输出时返回false
+func (p *htmlPolicy) render(b *strings.Builder, node *html.Node) bool {
+	switch node.Type {
+	case html.TextNode:
+		// 表格被去掉时，行与单元格之间的空白没有意义
+		if parent := node.Parent; parent != nil && tableElements[parent.Data] && strings.TrimSpace(node.Data) == "" {
+			if _, ok := p.elements[parent.Data]; !ok {
+				return false
+			}
+		}
+		b.WriteString(html.EscapeString(node.Data))
+		return node.Data != ""
+	case html.ElementNode:
+		tag := node.Data
+		if droppedElements[tag] {
+			return false
+		}
+		if renamed, ok := p.rename[tag]; ok {
+			tag = renamed
+		}
+		allowed, ok := p.elements[tag]
+		if !ok || (tag == "input" && attrValue(node, "type") != "checkbox") {
+			written := p.renderChildren(b, node)
+			// 去掉单元格标签后用空格分隔内容
+			if tag == "td" || tag == "th" {
+				b.WriteString(" ")
+				written = true
+			}
+			return written
+		}
+
+		var inner strings.Builder
+		if !voidElements[tag] {
+			p.renderChildren(&inner, node)
+			// 内容（如评论中的图片）被去掉后不留下空段落
+			if tag == "p" && strings.TrimSpace(inner.String()) == "" {
+				return false
+			}
+		}
+
+		b.WriteString("<" + tag)
+		for _, attr := range node.Attr {
+			if attr.Namespace == "" && contains(allowed, attr.Key) && validAttr(tag, attr.Key, attr.Val) {
+				b.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+			}
+		}
+		// 用户内容中的链接不传递权重，也不暴露来源页面
+		if tag == "a" {
+			b.WriteString(` rel="nofollow noopener noreferrer"`)
+		}
+		if voidElements[tag] {
+			b.WriteString(" />")
+			return true
+		}
+		b.WriteString(">")
+		b.WriteString(inner.String())
+		b.WriteString("</" + tag + ">")
+		return true
+	case html.DocumentNode:
+		return p.renderChildren(b, node)
+	}
+	return false
+}
+
+// renderChildren 依次输出子节点，返回是否有输出
+func (p *htmlPolicy) renderChildren(b *strings.Builder, node *html.Node) bool {
+	var children []*html.Node
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		children = append(children, child)
+	}
+	return p.renderNodes(b, children)
+}
+
+// renderNodes 依次输出节点，被整个去掉的节点后面的换行一并去掉，返回是否有输出
+func (p *htmlPolicy) renderNodes(b *strings.Builder, nodes []*html.Node) bool {
+	written, skipped := false, false
+	for _, node := range nodes {
+		if skipped && node.Type == html.TextNode && node.Data == "\n" {
+			skipped = false
+			continue
+		}
+		ok := p.render(b, node)
+		written = written || ok
+		skipped = !ok && node.Type == html.ElementNode
+	}
+	return written
+}
+
+// validAttr 校验属性值：链接只允许安全的协议，其它属性只允许固定格式
+func validAttr(tag, key, value string) bool {
+	switch key {
+	case "href", "src":
+		return isSafeURL(value)
+	case "class":
+		return codeLanguageClass.MatchString(value)
+	case "align":
+		return value == "left" || value == "center" || value == "right"
+	case "start":
+		for _, r := range value {
+			if r < '0' || r > '9' {
+				return false
+			}
+		}
+		return value != "" && len(value) <= 9
+	case "type":
+		return value == "checkbox"
+	}
+	return true
+}
+
+// isSafeURL 判断URL是否为http、https、mailto或相对地址，拒绝 javascript: 等协议
+func isSafeURL(value string) bool {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || safeSchemes[strings.ToLower(u.Scheme)]
+}
+
+// attrValue 获取元素的属性值
+func attrValue(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// contains 判断字符串切片是否包含指定值
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxSlugLength 别名的最大字符数
+const MaxSlugLength = 80
+
+// slugReplacements 分解后无法去掉变音符号的拉丁字母
+var slugReplacements = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'ł': "l", 'þ': "th",
+}
+
+// Slugify 将标题转换为URL别名：拉丁字母去掉变音符号并转为小写，
+// 中文等其它文字原样保留（浏览器会进行百分号编码），其余字符替换为连字符。
+// 没有可用字符时返回空字符串
+func Slugify(title string) string {
+	var words []string
+	var word strings.Builder
+	length := 0
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range norm.NFKD.String(strings.ToLower(title)) {
+		if length >= MaxSlugLength {
+			break
+		}
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// 去掉分解出的变音符号
+		case slugReplacements[r] != "":
+			word.WriteString(slugReplacements[r])
+			length += len(slugReplacements[r])
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+			length++
+		default:
+			flush()
+		}
+	}
+	flush()
+	return strings.Join(words, "-")
+}
+
+// IsValidSlug 判断自定义别名是否已经是规范形式
+func IsValidSlug(slug string) bool {
+	return slug != "" && Slugify(slug) == slug
+}